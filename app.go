@@ -4,10 +4,13 @@ import (
 	"context"
 	"flag"
 	"fmt"
+	"io"
 	"io/ioutil"
 	"log"
+	"net/http"
 	"os"
 	"strings"
+	"time"
 
 	realGithub "github.com/google/go-github/v28/github"
 	"go.starlark.net/syntax"
@@ -15,7 +18,12 @@ import (
 
 	"github.com/zegl/bazel_dependency_tools/http_archive"
 	"github.com/zegl/bazel_dependency_tools/internal"
+	"github.com/zegl/bazel_dependency_tools/internal/download"
 	"github.com/zegl/bazel_dependency_tools/internal/github"
+	"github.com/zegl/bazel_dependency_tools/internal/gitlab"
+	"github.com/zegl/bazel_dependency_tools/internal/group"
+	"github.com/zegl/bazel_dependency_tools/internal/osv"
+	"github.com/zegl/bazel_dependency_tools/internal/policy"
 	"github.com/zegl/bazel_dependency_tools/maven_jar"
 	"github.com/zegl/bazel_dependency_tools/parse"
 )
@@ -24,29 +32,166 @@ func main() {
 	flagPrefixFilter := flag.String("prefix", "", "Only attempt to upgrade dependencies with this prefix, if prefix is empty (default) all dependencies will be upgraded")
 	flagWorkspace := flag.String("workspace", "WORKSPACE", "Path to the WORKSPACE file")
 	flagFindLicenses := flag.Bool("find-licenses", false, "Runin find licenses mode")
+	flagConfig := flag.String("config", "", "Path to a JSON configuration file")
+	flagGroup := flag.String("group", "", "Only upgrade the dependencies in this group, if group is empty (default) all dependencies will be upgraded")
+	flagFollowCommits := flag.Bool("follow-commits", false, "Upgrade GitHub archives that are pinned to a commit to the newest commit on the default branch")
+	flagVerify := flag.Bool("verify", false, "Run in verify mode, download all dependencies at their current version and verify their checksums")
+	flagPin := flag.Bool("pin", false, "Run in pin mode, add a sha256 to all archives that don't have one")
+	flagMigrateIntegrity := flag.Bool("migrate-integrity", false, "Convert the sha256 of all archives to integrity attributes")
+	flagLicensePolicy := flag.String("license-policy", "", "Run in license policy mode, check the licenses of all dependencies against the JSON policy file at this path")
+	flagRepositoryCache := flag.String("repository-cache", download.DefaultRepositoryCache(), "Path to Bazel's repository cache, downloaded files are added to the cache. Set to an empty string to disable the cache")
+	flagFormat := flag.String("format", "csv", "The format of the -find-licenses report, \"csv\", \"json\" or \"markdown\"")
+	flagNotices := flag.Bool("notices", false, "Run in notices mode, print a THIRD_PARTY_NOTICES file with the license texts and NOTICE files of all dependencies")
+	flagSBOM := flag.String("sbom", "", "Run in SBOM mode, print an SBOM of all dependencies in the \"spdx\" or \"cyclonedx\" JSON format")
+	flagAudit := flag.String("audit", "", "Run in audit mode, check all dependencies against the OSV vulnerability database in this directory or zip file")
+	flagSecurityUpgrade := flag.Bool("security-upgrade", false, "Upgrade Maven artifacts with vulnerabilities to the versions that fix them, used with -audit")
 	flag.Parse()
 
+	downloader := &download.Downloader{Cache: *flagRepositoryCache}
+
+	cfg, err := loadConfig(*flagConfig)
+	if err != nil {
+		log.Fatalf("failed to load config: %s", err)
+	}
+
 	if *flagFindLicenses {
-		findLicenses(*flagWorkspace, *flagPrefixFilter)
+		gitHubClients, err := newGitHubClients(cfg)
+		if err != nil {
+			log.Fatalf("failed to create GitHub clients: %s", err)
+		}
+		if err := findLicenses(os.Stdout, *flagFormat, *flagWorkspace, *flagPrefixFilter, downloader, gitHubClients); err != nil {
+			log.Fatal(err)
+		}
+		return
+	}
+
+	if *flagNotices {
+		gitHubClients, err := newGitHubClients(cfg)
+		if err != nil {
+			log.Fatalf("failed to create GitHub clients: %s", err)
+		}
+		if err := writeNotices(os.Stdout, *flagWorkspace, *flagPrefixFilter, downloader, gitHubClients); err != nil {
+			log.Fatal(err)
+		}
+		return
+	}
+
+	if *flagSBOM != "" {
+		gitHubClients, err := newGitHubClients(cfg)
+		if err != nil {
+			log.Fatalf("failed to create GitHub clients: %s", err)
+		}
+		if err := writeSBOM(os.Stdout, *flagSBOM, *flagWorkspace, *flagPrefixFilter, downloader, gitHubClients); err != nil {
+			log.Fatal(err)
+		}
+		return
+	}
+
+	if *flagAudit != "" {
+		db, err := osv.Load(*flagAudit)
+		if err != nil {
+			log.Fatalf("failed to load vulnerability database: %s", err)
+		}
+		_, packages := collectPackages(*flagWorkspace, *flagPrefixFilter)
+		findings := auditPackages(packages, db)
+		ok := printAudit(os.Stdout, findings)
+
+		if *flagSecurityUpgrade {
+			upgrades := securityUpgrades(*flagWorkspace, *flagPrefixFilter, findings, maven_jar.FixedVersionResolver)
+			results := group.Resolve(upgrades, nil)
+			printUpgradeSummary(os.Stdout, results, "")
+			if err := applyReplacements(*flagWorkspace, group.Replacements(results, "")); err != nil {
+				log.Fatalf("failed to apply security upgrades: %s", err)
+			}
+		}
+
+		if !ok {
+			os.Exit(1)
+		}
+		return
+	}
+
+	if *flagLicensePolicy != "" {
+		p, err := policy.Load(*flagLicensePolicy)
+		if err != nil {
+			log.Fatalf("failed to load license policy: %s", err)
+		}
+		gitHubClients, err := newGitHubClients(cfg)
+		if err != nil {
+			log.Fatalf("failed to create GitHub clients: %s", err)
+		}
+		if !checkLicensePolicy(os.Stdout, collectLicenses(*flagWorkspace, *flagPrefixFilter, downloader, gitHubClients), p) {
+			os.Exit(1)
+		}
+		return
+	}
+
+	if *flagPin {
+		if err := pinChecksums(*flagWorkspace, *flagPrefixFilter, downloader); err != nil {
+			log.Fatalf("failed to pin checksums: %s", err)
+		}
+		return
+	}
+
+	if *flagMigrateIntegrity {
+		if err := migrateIntegrity(*flagWorkspace, *flagPrefixFilter); err != nil {
+			log.Fatalf("failed to migrate to integrity: %s", err)
+		}
+		return
+	}
+
+	if *flagVerify {
+		if !verifyChecksums(os.Stdout, *flagWorkspace, *flagPrefixFilter, downloader) {
+			os.Exit(1)
+		}
 		return
 	}
 
-	versionUpgrades(*flagWorkspace, *flagPrefixFilter)
+	versionUpgrades(*flagWorkspace, *flagPrefixFilter, cfg, *flagGroup, *flagFollowCommits, downloader)
 }
 
-func versionUpgrades(workspace, prefixFilter string) {
-	ctx := context.Background()
-	ts := oauth2.StaticTokenSource(
-		&oauth2.Token{AccessToken: os.Getenv("GITHUB_TOKEN")},
-	)
-	tc := oauth2.NewClient(ctx, ts)
-	gitHubClient := github.NewGithubClient(realGithub.NewClient(tc))
+func versionUpgrades(workspace, prefixFilter string, cfg *config, onlyGroup string, followCommits bool, downloader *download.Downloader) {
+	gitHubClients, err := newGitHubClients(cfg)
+	if err != nil {
+		log.Fatalf("failed to create GitHub clients: %s", err)
+	}
+
+	sources := http_archive.Sources{
+		GitHub:   gitHubClients,
+		GitLab:   gitlab.NewGitlabClient(http.DefaultClient, "https://gitlab.com/api/v4", os.Getenv("GITLAB_TOKEN")),
+		Index:    cfg.DownloadHosts,
+		Download: downloader,
+
+		FollowCommits:  followCommits,
+		CommitBranches: cfg.CommitBranches,
+	}
+
+	upgrades := findUpgrades(workspace, prefixFilter, sources, maven_jar.NewestAvailable)
+	if aligned := group.AlignedVersions(upgrades, cfg.Groups); len(aligned) > 0 {
+		upgrades = alignUpgrades(upgrades, mavenUpgrades(workspace, prefixFilter, maven_jar.FixedVersionResolver(aligned)), aligned)
+	}
+	results := group.Resolve(upgrades, cfg.Groups)
+	printUpgradeSummary(os.Stdout, results, onlyGroup)
+	lineReplacements := group.Replacements(results, onlyGroup)
+
+	if err := applyReplacements(workspace, lineReplacements); err != nil {
+		panic(err)
+	}
 
-	lineReplacements := versionUpgradeReplacements(workspace, prefixFilter, gitHubClient, maven_jar.NewestAvailable)
+	for host, c := range gitHubClients {
+		if rc, ok := c.(interface{ Rate() realGithub.Rate }); ok {
+			if rate := rc.Rate(); rate.Limit > 0 {
+				log.Printf("GitHub API rate limit for %s: %d/%d requests remaining, resets at %s", host, rate.Remaining, rate.Limit, rate.Reset.Format(time.RFC3339))
+			}
+		}
+	}
+}
 
+// applyReplacements performs all replacements in the workspace file
+func applyReplacements(workspace string, lineReplacements []internal.LineReplacement) error {
 	rawContent, err := ioutil.ReadFile(workspace)
 	if err != nil {
-		panic(err)
+		return err
 	}
 
 	rows := strings.Split(string(rawContent), "\n")
@@ -57,31 +202,54 @@ func versionUpgrades(workspace, prefixFilter string) {
 	}
 
 	// Write the new file
-	err = ioutil.WriteFile(workspace, []byte(strings.Join(rows, "\n")), 0777)
-	if err != nil {
-		panic(err)
+	return ioutil.WriteFile(workspace, []byte(strings.Join(rows, "\n")), 0777)
+}
+
+// newGitHubClients creates API clients for github.com, and for all GitHub hosts in the config
+func newGitHubClients(cfg *config) (github.Hosts, error) {
+	newHTTPClient := func(token string) *http.Client {
+		ts := oauth2.StaticTokenSource(
+			&oauth2.Token{AccessToken: token},
+		)
+		tc := oauth2.NewClient(context.Background(), ts)
+		tc.Transport = github.NewETagTransport(tc.Transport)
+		return tc
+	}
+
+	clients := github.Hosts{
+		"github.com": github.NewGithubClient(realGithub.NewClient(newHTTPClient(os.Getenv("GITHUB_TOKEN")))),
 	}
+
+	for _, h := range cfg.GitHubHosts {
+		c, err := realGithub.NewEnterpriseClient(h.APIURL, h.APIURL, newHTTPClient(os.Getenv(h.TokenEnv)))
+		if err != nil {
+			return nil, fmt.Errorf("%s: %w", h.Host, err)
+		}
+		clients[h.Host] = github.NewGithubClient(c)
+	}
+
+	return clients, nil
 }
 
-func versionUpgradeReplacements(workspace, prefixFilter string, gitHubClient github.Client, versionFunc maven_jar.NewestVersionResolver) []internal.LineReplacement {
-	var lineReplacements []internal.LineReplacement
+func findUpgrades(workspace, prefixFilter string, sources http_archive.Sources, versionFunc maven_jar.NewestVersionResolver) []internal.Upgrade {
+	var upgrades []internal.Upgrade
 
 	callFuncs := map[string]parse.FuncHook{
 		"maven_jar": func(s *syntax.CallExpr, namePrefixFilter string, workspacePath string) error {
-			if r, err := maven_jar.Check(s, namePrefixFilter, versionFunc); err == nil {
-				lineReplacements = append(lineReplacements, r...)
+			if u, err := maven_jar.Check(s, namePrefixFilter, versionFunc); err == nil {
+				upgrades = append(upgrades, u...)
 			}
 			return nil
 		},
 		"http_archive": func(s *syntax.CallExpr, namePrefixFilter string, workspacePath string) error {
-			if archiveReplacements, err := http_archive.Check(s, namePrefixFilter, gitHubClient); err == nil {
-				lineReplacements = append(lineReplacements, archiveReplacements...)
+			if u, err := http_archive.Check(s, namePrefixFilter, sources); err == nil {
+				upgrades = append(upgrades, u...)
 			}
 			return nil
 		},
 		"maven_install": func(s *syntax.CallExpr, namePrefixFilter string, workspacePath string) error {
-			if archiveReplacements, err := maven_jar.CheckInstall(s, namePrefixFilter, versionFunc); err == nil {
-				lineReplacements = append(lineReplacements, archiveReplacements...)
+			if u, err := maven_jar.CheckInstall(s, namePrefixFilter, versionFunc); err == nil {
+				upgrades = append(upgrades, u...)
 			}
 			return nil
 		},
@@ -89,37 +257,193 @@ func versionUpgradeReplacements(workspace, prefixFilter string, gitHubClient git
 
 	parse.ParseWorkspace(workspace, prefixFilter, callFuncs)
 
-	return lineReplacements
+	return upgrades
 }
 
-func findLicenses(workspace, prefixFilter string) {
+// mavenUpgrades checks maven_jar and the artifacts of maven_install for newer
+// versions with versionFunc
+func mavenUpgrades(workspace, prefixFilter string, versionFunc maven_jar.NewestVersionResolver) []internal.Upgrade {
+	var upgrades []internal.Upgrade
+
 	callFuncs := map[string]parse.FuncHook{
 		"maven_jar": func(s *syntax.CallExpr, namePrefixFilter string, workspacePath string) error {
-			name, license, err := maven_jar.License(s, namePrefixFilter)
-			if err == maven_jar.ErrSkipped {
-				return nil
-			}
-			if err != nil {
-				log.Println(name, err)
-				return nil
+			if u, err := maven_jar.Check(s, namePrefixFilter, versionFunc); err == nil {
+				upgrades = append(upgrades, u...)
 			}
-			fmt.Printf("%s,%s\n", name, license)
 			return nil
 		},
 		"maven_install": func(s *syntax.CallExpr, namePrefixFilter string, workspacePath string) error {
-			licenses, err := maven_jar.LicenseMavenInstall(s, namePrefixFilter, workspacePath)
-			if err == maven_jar.ErrSkipped {
-				return nil
+			if u, err := maven_jar.CheckInstall(s, namePrefixFilter, versionFunc); err == nil {
+				upgrades = append(upgrades, u...)
 			}
-			if err != nil {
-				log.Println(err)
-				return nil
+			return nil
+		},
+	}
+
+	parse.ParseWorkspace(workspace, prefixFilter, callFuncs)
+
+	return upgrades
+}
+
+// alignUpgrades replaces the upgrades of the Maven artifacts in aligned, by
+// coordinate, with their upgrade to the aligned version
+func alignUpgrades(upgrades, alignedUpgrades []internal.Upgrade, aligned map[string]string) []internal.Upgrade {
+	res := append([]internal.Upgrade(nil), upgrades...)
+	for i, u := range res {
+		if _, ok := aligned[u.Coordinate]; !ok {
+			continue
+		}
+		for _, a := range alignedUpgrades {
+			if a.Rule == u.Rule && a.Name == u.Name && a.Coordinate == u.Coordinate {
+				res[i] = a
+				break
 			}
-			for _, l := range licenses {
-				fmt.Printf("%s,%s\n", l.Art, l.License)
+		}
+	}
+	return res
+}
+
+// printUpgradeSummary writes a description of all upgrades, one paragraph per group
+func printUpgradeSummary(w io.Writer, results []group.Result, onlyGroup string) {
+	for _, r := range results {
+		if onlyGroup != "" && r.Group != onlyGroup {
+			continue
+		}
+
+		title := "Upgrade"
+		if r.Group != "" {
+			title = "Upgrade group " + r.Group
+		}
+
+		if r.Err != nil {
+			if r.Group != "" {
+				fmt.Fprintf(w, "Skipped group %s: %s\n\n", r.Group, r.Err)
 			}
+			continue
+		}
+
+		var lines, notes []string
+		for _, u := range r.Upgrades {
+			if u.NewVersion == u.OldVersion {
+				continue
+			}
+			lines = append(lines, fmt.Sprintf("* %s (%s): %s -> %s", u.Name, u.Coordinate, u.OldVersion, u.NewVersion))
+
+			if u.ReleaseNotes != "" || u.CompareURL != "" {
+				note := fmt.Sprintf("## %s %s", u.Name, u.NewVersion)
+				if u.CompareURL != "" {
+					note += "\n\nChanges: " + u.CompareURL
+				}
+				if u.ReleaseNotes != "" {
+					note += "\n\n" + u.ReleaseNotes
+				}
+				notes = append(notes, note)
+			}
+		}
+		if len(lines) == 0 {
+			continue
+		}
+
+		fmt.Fprintf(w, "%s\n\n%s\n\n", title, strings.Join(lines, "\n"))
+		for _, note := range notes {
+			fmt.Fprintf(w, "%s\n\n", note)
+		}
+	}
+}
+
+// pinChecksums adds a sha256 to all archives and files that don't declare one
+func pinChecksums(workspace, prefixFilter string, downloader *download.Downloader) error {
+	var lineReplacements []internal.LineReplacement
+
+	hook := func(s *syntax.CallExpr, namePrefixFilter string, workspacePath string) error {
+		r, err := http_archive.Pin(s, namePrefixFilter, downloader)
+		if err != nil {
+			log.Println(err)
 			return nil
+		}
+		lineReplacements = append(lineReplacements, r...)
+		return nil
+	}
+
+	callFuncs := map[string]parse.FuncHook{
+		"http_archive": hook,
+		"http_file":    hook,
+		"http_jar":     hook,
+	}
+	parse.ParseWorkspace(workspace, prefixFilter, callFuncs)
+
+	return applyReplacements(workspace, lineReplacements)
+}
+
+// migrateIntegrity replaces the sha256 of all archives and files with an
+// integrity attribute
+func migrateIntegrity(workspace, prefixFilter string) error {
+	var lineReplacements []internal.LineReplacement
+
+	hook := func(s *syntax.CallExpr, namePrefixFilter string, workspacePath string) error {
+		r, err := http_archive.MigrateIntegrity(s, namePrefixFilter)
+		if err != nil {
+			log.Println(err)
+			return nil
+		}
+		lineReplacements = append(lineReplacements, r...)
+		return nil
+	}
+
+	callFuncs := map[string]parse.FuncHook{
+		"http_archive": hook,
+		"http_file":    hook,
+		"http_jar":     hook,
+	}
+	parse.ParseWorkspace(workspace, prefixFilter, callFuncs)
+
+	return applyReplacements(workspace, lineReplacements)
+}
+
+// verifyChecksums verifies the checksums of all dependencies, and returns false
+// if any of them doesn't match or could not be verified
+func verifyChecksums(w io.Writer, workspace, prefixFilter string, downloader *download.Downloader) bool {
+	var verifications []internal.Verification
+
+	add := func(v *internal.Verification, err error) error {
+		if err != nil {
+			log.Println(err)
+			return nil
+		}
+		if v != nil {
+			verifications = append(verifications, *v)
+		}
+		return nil
+	}
+
+	httpHook := func(rule string) parse.FuncHook {
+		return func(s *syntax.CallExpr, namePrefixFilter string, workspacePath string) error {
+			return add(http_archive.Verify(s, rule, namePrefixFilter, downloader))
+		}
+	}
+
+	callFuncs := map[string]parse.FuncHook{
+		"http_archive": httpHook("http_archive"),
+		"http_file":    httpHook("http_file"),
+		"http_jar":     httpHook("http_jar"),
+		"maven_jar": func(s *syntax.CallExpr, namePrefixFilter string, workspacePath string) error {
+			return add(maven_jar.Verify(s, namePrefixFilter, downloader))
 		},
 	}
 	parse.ParseWorkspace(workspace, prefixFilter, callFuncs)
+
+	ok := true
+	for _, v := range verifications {
+		switch v.Err {
+		case nil:
+			fmt.Fprintf(w, "OK %s %s\n", v.Rule, v.Name)
+		case internal.ErrNoChecksum:
+			fmt.Fprintf(w, "%s:%d: WARNING %s %s: %s\n", v.Filename, v.Line, v.Rule, v.Name, v.Err)
+		default:
+			ok = false
+			fmt.Fprintf(w, "%s:%d: FAIL %s %s: %s\n", v.Filename, v.Line, v.Rule, v.Name, v.Err)
+		}
+	}
+
+	return ok
 }
@@ -0,0 +1,121 @@
+package main
+
+import (
+	"fmt"
+	"io"
+	"log"
+	"sort"
+	"strings"
+
+	"github.com/zegl/bazel_dependency_tools/http_archive"
+	"github.com/zegl/bazel_dependency_tools/internal"
+	"github.com/zegl/bazel_dependency_tools/internal/osv"
+	"github.com/zegl/bazel_dependency_tools/internal/sbom"
+	"github.com/zegl/bazel_dependency_tools/maven_jar"
+)
+
+// auditFinding is a vulnerability that affects a dependency
+type auditFinding struct {
+	Package sbom.Package
+	osv.Finding
+}
+
+// auditPackages matches Maven artifacts, Go modules and GitHub repositories
+// against the advisories in the database. GitHub repositories that are pinned
+// to a commit can't be matched, as the commits of the advisories' GIT ranges
+// are only known with the history of the repository, and are logged instead.
+func auditPackages(packages []sbom.Package, db *osv.Database) []auditFinding {
+	var res []auditFinding
+	for _, p := range packages {
+		var findings []osv.Finding
+		coordinate := packageCoordinate(p)
+		switch {
+		case p.Group != "":
+			findings = db.Query(osv.Maven, coordinate, p.Version)
+		case strings.HasPrefix(p.PURL, "pkg:golang/"):
+			findings = db.Query(osv.Go, coordinate, p.Version)
+		case strings.HasPrefix(p.PURL, "pkg:github/") && http_archive.IsCommit(p.Version):
+			log.Printf("%s %s: not audited, pinned to commit %s", p.Rule, p.Name, p.Version)
+		case strings.HasPrefix(p.PURL, "pkg:github/"):
+			findings = db.QueryGit(coordinate, p.Version)
+		}
+
+		for _, f := range findings {
+			res = append(res, auditFinding{Package: p, Finding: f})
+		}
+	}
+	return res
+}
+
+// printAudit writes all findings, and returns false if there are any
+func printAudit(w io.Writer, findings []auditFinding) bool {
+	for _, f := range findings {
+		id := f.Vulnerability.ID
+		if len(f.Vulnerability.Aliases) > 0 {
+			id += " (" + strings.Join(f.Vulnerability.Aliases, ", ") + ")"
+		}
+
+		fixed := "no fix available"
+		if f.Fixed != "" {
+			fixed = "fixed in " + f.Fixed
+		}
+
+		fmt.Fprintf(w, "%s %s %s: %s, severity %s, affected %s, %s\n", f.Package.Rule, f.Package.Name, f.Package.Version, id, f.Vulnerability.SeverityString(), f.Affected, fixed)
+		if f.Vulnerability.Summary != "" {
+			fmt.Fprintf(w, "  %s\n", f.Vulnerability.Summary)
+		}
+	}
+
+	return len(findings) == 0
+}
+
+// securityFixes returns the version that fixes all vulnerabilities of each
+// Maven artifact, by its "group:artifact", and the IDs of the vulnerabilities
+// that it fixes
+func securityFixes(findings []auditFinding) (map[string]string, map[string][]string) {
+	fixes := make(map[string]string)
+	ids := make(map[string][]string)
+
+	for _, f := range findings {
+		if f.Package.Group == "" || f.Fixed == "" {
+			continue
+		}
+
+		coordinate := packageCoordinate(f.Package)
+		if current, ok := fixes[coordinate]; !ok {
+			fixes[coordinate] = f.Fixed
+		} else if c, ok := osv.Compare(osv.Maven, f.Fixed, current); ok && c > 0 {
+			fixes[coordinate] = f.Fixed
+		}
+		ids[coordinate] = appendUnique(ids[coordinate], f.Vulnerability.ID)
+	}
+
+	return fixes, ids
+}
+
+func appendUnique(list []string, s string) []string {
+	for _, v := range list {
+		if v == s {
+			return list
+		}
+	}
+	list = append(list, s)
+	sort.Strings(list)
+	return list
+}
+
+// securityUpgrades upgrades maven_jar and the artifacts of maven_install to
+// the versions that fix their vulnerabilities. Transitive dependencies of
+// maven_install can't be upgraded, and are only reported by the audit.
+func securityUpgrades(workspace, prefixFilter string, findings []auditFinding, versionFunc func(map[string]string) maven_jar.NewestVersionResolver) []internal.Upgrade {
+	fixes, ids := securityFixes(findings)
+
+	upgrades := mavenUpgrades(workspace, prefixFilter, versionFunc(fixes))
+	for i, u := range upgrades {
+		if u.NewVersion != u.OldVersion {
+			upgrades[i].ReleaseNotes = "Fixes " + strings.Join(ids[u.Coordinate], ", ")
+		}
+	}
+
+	return upgrades
+}
@@ -0,0 +1,87 @@
+package main
+
+import (
+	"bytes"
+	"strings"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+
+	"github.com/zegl/bazel_dependency_tools/internal/osv"
+	"github.com/zegl/bazel_dependency_tools/internal/sbom"
+	"github.com/zegl/bazel_dependency_tools/maven_jar"
+)
+
+func TestAuditPackages(t *testing.T) {
+	db, err := osv.Load("internal/osv/testdata")
+	assert.Nil(t, err)
+
+	_, packages := collectPackages("testdata/sbom_WORKSPACE", "")
+	findings := auditPackages(packages, db)
+
+	var ids []string
+	for _, f := range findings {
+		ids = append(ids, f.Package.Name+" "+f.Vulnerability.ID+" "+f.Fixed)
+	}
+	assert.Equal(t, []string{
+		"bazel_skylib OSV-TEST-0001 8f2f6b8e6d3c1f3e1b7c2e3f2b1f0e9d8c7b6a59",
+		"com_github_google_go_github_v28 GO-TEST-0001 28.1.2",
+		"junit_junit GHSA-269g-pwp5-87pp 4.13.1",
+		"com.google.guava:guava:28.1-jre GHSA-5mg8-w23w-74h3 30.0-jre",
+		"com.google.guava:guava:28.1-jre GHSA-7g45-4rm6-3mm3 ",
+		"com.google.guava:guava:jar:sources:28.1-jre GHSA-5mg8-w23w-74h3 30.0-jre",
+		"com.google.guava:guava:jar:sources:28.1-jre GHSA-7g45-4rm6-3mm3 ",
+	}, ids)
+
+	var buf bytes.Buffer
+	assert.False(t, printAudit(&buf, findings))
+	assert.Contains(t, buf.String(), "maven_jar junit_junit 4.12: GHSA-269g-pwp5-87pp (CVE-2020-15250), severity CVSS_V3 CVSS:3.1/AV:L/AC:L/PR:L/UI:N/S:U/C:L/I:N/A:N, affected >= 4.7, < 4.13.1, fixed in 4.13.1\n")
+	assert.Contains(t, buf.String(), "maven_install com.google.guava:guava:28.1-jre 28.1-jre: GHSA-7g45-4rm6-3mm3 (CVE-2023-2976), severity MODERATE, affected >= 1.0, < 32.0.0-android, no fix available\n")
+
+	// Commits can't be matched against GIT ranges
+	commit := "8f2f6b8e6d3c1f3e1b7c2e3f2b1f0e9d8c7b6a59"
+	assert.Empty(t, auditPackages([]sbom.Package{{
+		Rule:    "http_archive",
+		Name:    "bazel_skylib",
+		Version: commit,
+		PURL:    "pkg:github/bazelbuild/bazel-skylib@" + commit,
+	}}, db))
+
+	buf.Reset()
+	assert.True(t, printAudit(&buf, nil))
+	assert.Empty(t, buf.String())
+}
+
+func TestSecurityUpgrades(t *testing.T) {
+	db, err := osv.Load("internal/osv/testdata")
+	assert.Nil(t, err)
+
+	_, packages := collectPackages("testdata/sbom_WORKSPACE", "")
+	findings := auditPackages(packages, db)
+
+	fixes, ids := securityFixes(findings)
+	assert.Equal(t, map[string]string{
+		"com.google.guava:guava": "30.0-jre",
+		"junit:junit":            "4.13.1",
+	}, fixes)
+	assert.Equal(t, []string{"GHSA-5mg8-w23w-74h3"}, ids["com.google.guava:guava"])
+
+	fakeResolver := func(fixes map[string]string) maven_jar.NewestVersionResolver {
+		return func(coordinate string) (string, string, error) {
+			xyz := strings.Split(coordinate, ":")
+			if version, ok := fixes[xyz[0]+":"+xyz[1]]; ok {
+				return version, "fakesha1", nil
+			}
+			return xyz[2], "", nil
+		}
+	}
+
+	upgrades := securityUpgrades("testdata/sbom_WORKSPACE", "", findings, fakeResolver)
+	assert.Len(t, upgrades, 2)
+	assert.Equal(t, "junit_junit", upgrades[0].Name)
+	assert.Equal(t, "4.13.1", upgrades[0].NewVersion)
+	assert.Equal(t, "Fixes GHSA-269g-pwp5-87pp", upgrades[0].ReleaseNotes)
+	assert.Equal(t, "com.google.guava:guava", upgrades[1].Coordinate)
+	assert.Equal(t, "30.0-jre", upgrades[1].NewVersion)
+	assert.Equal(t, "Fixes GHSA-5mg8-w23w-74h3", upgrades[1].ReleaseNotes)
+}
@@ -0,0 +1,54 @@
+package main
+
+import (
+	"encoding/json"
+	"io/ioutil"
+
+	"github.com/zegl/bazel_dependency_tools/internal/group"
+	"github.com/zegl/bazel_dependency_tools/internal/index"
+)
+
+type config struct {
+	Groups      []group.Group `json:"groups"`
+	GitHubHosts []gitHubHost  `json:"github_hosts"`
+
+	// DownloadHosts are plain web servers where new versions are found by
+	// listing directories
+	DownloadHosts index.Hosts `json:"download_hosts"`
+
+	// CommitBranches maps repository names of GitHub archives that are pinned
+	// to a commit to the branch that they should follow
+	CommitBranches map[string]string `json:"commit_branches"`
+}
+
+// gitHubHost is a GitHub instance other than github.com, such as a GitHub
+// Enterprise server
+type gitHubHost struct {
+	Host string `json:"host"`
+
+	// APIURL is the base URL of the REST API, eg. "https://github.example.corp/api/v3/"
+	APIURL string `json:"api_url"`
+
+	// TokenEnv is the name of the environment variable containing the access token
+	TokenEnv string `json:"token_env"`
+}
+
+// loadConfig reads the JSON configuration file at path. An empty path yields
+// the default configuration.
+func loadConfig(path string) (*config, error) {
+	var cfg config
+	if path == "" {
+		return &cfg, nil
+	}
+
+	data, err := ioutil.ReadFile(path)
+	if err != nil {
+		return nil, err
+	}
+
+	if err := json.Unmarshal(data, &cfg); err != nil {
+		return nil, err
+	}
+
+	return &cfg, nil
+}
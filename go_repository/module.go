@@ -0,0 +1,63 @@
+package go_repository
+
+import (
+	"fmt"
+	"strings"
+
+	"go.starlark.net/syntax"
+)
+
+// Module is the Go module of a go_repository
+type Module struct {
+	Name       string // The repository name
+	ImportPath string
+	Version    string // The module version, or the commit or tag if it's fetched with git
+}
+
+// ParseModule returns the module of a go_repository, or nil if it's skipped
+func ParseModule(e *syntax.CallExpr, namePrefixFilter string) (*Module, error) {
+	var m Module
+	var commit, tag string
+
+	for _, arg := range e.Args {
+		if binExp, ok := arg.(*syntax.BinaryExpr); ok && binExp.Op == syntax.EQ {
+			if xIdent, ok := binExp.X.(*syntax.Ident); ok {
+				rhs, ok := binExp.Y.(*syntax.Literal)
+				if !ok {
+					continue
+				}
+				switch xIdent.Name {
+				case "name":
+					m.Name = rhs.Value.(string)
+				case "importpath":
+					m.ImportPath = rhs.Value.(string)
+				case "version":
+					m.Version = rhs.Value.(string)
+				case "commit":
+					commit = rhs.Value.(string)
+				case "tag":
+					tag = rhs.Value.(string)
+				}
+			}
+		}
+	}
+
+	// Don't check this dependency
+	if !strings.HasPrefix(m.Name, namePrefixFilter) {
+		return nil, nil
+	}
+
+	if m.ImportPath == "" {
+		return nil, fmt.Errorf("unable to parse %s", m.Name)
+	}
+
+	switch {
+	case m.Version != "":
+	case tag != "":
+		m.Version = tag
+	default:
+		m.Version = commit
+	}
+
+	return &m, nil
+}
@@ -0,0 +1,49 @@
+package go_repository
+
+import (
+	"fmt"
+	"strings"
+	"unicode"
+
+	"go.starlark.net/syntax"
+
+	"github.com/zegl/bazel_dependency_tools/internal/sbom"
+)
+
+// Package describes a go_repository for an SBOM. The license is not set, and
+// the package is nil if it was skipped.
+func Package(e *syntax.CallExpr, namePrefixFilter string) (*sbom.Package, error) {
+	m, err := ParseModule(e, namePrefixFilter)
+	if m == nil || err != nil {
+		return nil, err
+	}
+
+	p := &sbom.Package{
+		Rule:    "go_repository",
+		Name:    m.Name,
+		Version: m.Version,
+		PURL:    sbom.PURL("golang", m.ImportPath, m.Version, nil),
+	}
+
+	// Modules that are fetched with git are not in the module proxy
+	if strings.HasPrefix(m.Version, "v") {
+		p.URL = fmt.Sprintf("https://proxy.golang.org/%s/@v/%s.zip", escapePath(m.ImportPath), escapePath(m.Version))
+	}
+
+	return p, nil
+}
+
+// escapePath escapes a module path or version for the module proxy, where
+// upper case letters are replaced with an exclamation mark followed by the
+// lower case letter
+func escapePath(s string) string {
+	var b strings.Builder
+	for _, r := range s {
+		if unicode.IsUpper(r) {
+			b.WriteByte('!')
+			r = unicode.ToLower(r)
+		}
+		b.WriteRune(r)
+	}
+	return b.String()
+}
@@ -4,31 +4,118 @@ import (
 	"crypto/sha256"
 	"errors"
 	"fmt"
-	"io/ioutil"
 	"log"
-	"net/http"
 	"regexp"
 	"strings"
 
 	"go.starlark.net/syntax"
 
 	"github.com/zegl/bazel_dependency_tools/internal"
+	"github.com/zegl/bazel_dependency_tools/internal/archive"
+	"github.com/zegl/bazel_dependency_tools/internal/download"
 	"github.com/zegl/bazel_dependency_tools/internal/github"
+	"github.com/zegl/bazel_dependency_tools/internal/gitlab"
+	"github.com/zegl/bazel_dependency_tools/internal/index"
+	"github.com/zegl/bazel_dependency_tools/internal/integrity"
 	isemver "github.com/zegl/bazel_dependency_tools/internal/semver"
-
-	realGithub "github.com/google/go-github/v28/github"
 )
 
-var gitHubReleaseRegex = regexp.MustCompile(`https://github\.com/([a-zA-Z0-9_-]+)/([a-zA-Z0-9_-]+)/releases/download/([a-z0-9\.]+)/(.*)\.tar\.gz`)
-var githubArchiveRegex = regexp.MustCompile(`https://github\.com/([a-zA-Z0-9_-]+)/([a-zA-Z0-9_-]+)/archive/([a-z0-9\.]+)\.zip`)
+var ErrNoNewerVersion = errors.New("no newer version found")
+
+// Release describes a newer version of an archive
+type Release struct {
+	OldVersion string
+	NewVersion string
+	Sha256     string
+
+	// Integrity is the Subresource Integrity of the new archive, by algorithm
+	Integrity map[string]string
+
+	// StripPrefix is the single top-level directory of the new archive, or
+	// empty if the archive could not be listed or has no such directory
+	StripPrefix string
+
+	// Notes are the release notes of all releases after OldVersion, up to and
+	// including NewVersion, newest first.
+	Notes      string
+	CompareURL string
+}
+
+// Sources are the services used to find newer versions of archives
+type Sources struct {
+	GitHub   github.Hosts
+	GitLab   gitlab.Client
+	Index    index.Hosts
+	Download *download.Downloader
+
+	// FollowCommits upgrades GitHub archives that are pinned to a commit to
+	// the newest commit on the default branch
+	FollowCommits bool
+
+	// CommitBranches maps repository names to the branch to follow for
+	// archives that are pinned to a commit, regardless of FollowCommits
+	CommitBranches map[string]string
+}
+
+// source returns the service that hosts the archive at url, or an empty string
+// if it's not hosted on any of the sources
+func (s Sources) source(url string) string {
+	if _, ok := s.GitHub.Match(gitHubHost(url)); ok {
+		return internal.SourceGitHub
+	}
+	if s.GitLab != nil && gitLabProject(url) != "" {
+		return internal.SourceGitLab
+	}
+	if _, ok := s.Index.Match(url); ok {
+		return internal.SourceIndex
+	}
+	return ""
+}
+
+// findNewerRelease looks up a newer release of the archive at url. The
+// returned coordinate is empty if url is not hosted on any of the sources.
+func (s Sources) findNewerRelease(name, url string) (coordinate string, release *Release, err error) {
+	switch s.source(url) {
+	case internal.SourceGitHub:
+		gitHubClient, _ := s.GitHub.Match(gitHubHost(url))
+		if ref := gitHubArchiveRef(url); commitRegex.MatchString(ref) {
+			branch, ok := s.CommitBranches[name]
+			if !ok && !s.FollowCommits {
+				return gitHubCoordinate(url), &Release{OldVersion: ref}, ErrNoNewerVersion
+			}
+			release, err := FindNewerGitHubCommit(gitHubClient, s.Download, url, branch)
+			return gitHubCoordinate(url), release, err
+		}
 
-func Check(e *syntax.CallExpr, namePrefixFilter string, gitHubClient github.Client) ([]internal.LineReplacement, error) {
-	var replacements []internal.LineReplacement
+		release, err := FindNewerGitHubRelease(gitHubClient, s.Download, url)
+		return gitHubCoordinate(url), release, err
 
-	var archiveName string
-	var archiveUrls []*syntax.Literal
-	var archiveSha256 *syntax.Literal
-	var archiveStripPrefix *syntax.Literal
+	case internal.SourceGitLab:
+		release, err := FindNewerGitLabRelease(s.GitLab, s.Download, url)
+		return "gitlab.com/" + gitLabProject(url), release, err
+
+	case internal.SourceIndex:
+		host, _ := s.Index.Match(url)
+		release, err := FindNewerIndexRelease(host, s.Download, url)
+		return indexCoordinate(url), release, err
+	}
+
+	return "", nil, nil
+}
+
+// attributes are the attributes of an http_archive, http_file or http_jar
+// that are relevant when upgrading or verifying it
+type attributes struct {
+	name        string
+	nameLiteral *syntax.Literal
+	urls        []*syntax.Literal
+	sha256      *syntax.Literal
+	integrity   *syntax.Literal
+	stripPrefix *syntax.Literal
+}
+
+func parseAttributes(e *syntax.CallExpr) attributes {
+	var attrs attributes
 
 	for _, arg := range e.Args {
 		if binExp, ok := arg.(*syntax.BinaryExpr); ok && binExp.Op == syntax.EQ {
@@ -36,33 +123,47 @@ func Check(e *syntax.CallExpr, namePrefixFilter string, gitHubClient github.Clie
 				switch xIdent.Name {
 				case "name":
 					if rhs, ok := binExp.Y.(*syntax.Literal); ok {
-						archiveName = rhs.Value.(string)
+						attrs.name = rhs.Value.(string)
+						attrs.nameLiteral = rhs
 					}
 				case "url":
 					if urlString, ok := binExp.Y.(*syntax.Literal); ok {
-						archiveUrls = append(archiveUrls, urlString)
+						attrs.urls = append(attrs.urls, urlString)
 					}
 				case "urls":
 					if urlsListExpr, ok := binExp.Y.(*syntax.ListExpr); ok {
 						for _, urlSingleListExpr := range urlsListExpr.List {
 							if urlString, ok := urlSingleListExpr.(*syntax.Literal); ok {
-								archiveUrls = append(archiveUrls, urlString)
+								attrs.urls = append(attrs.urls, urlString)
 							}
 						}
 					}
 				case "sha256":
 					if rhs, ok := binExp.Y.(*syntax.Literal); ok {
-						archiveSha256 = rhs
+						attrs.sha256 = rhs
+					}
+				case "integrity":
+					if rhs, ok := binExp.Y.(*syntax.Literal); ok {
+						attrs.integrity = rhs
 					}
 				case "strip_prefix":
 					if rhs, ok := binExp.Y.(*syntax.Literal); ok {
-						archiveStripPrefix = rhs
+						attrs.stripPrefix = rhs
 					}
 				}
 			}
 		}
 	}
 
+	return attrs
+}
+
+func Check(e *syntax.CallExpr, namePrefixFilter string, sources Sources) ([]internal.Upgrade, error) {
+	var failed []internal.Upgrade
+
+	attrs := parseAttributes(e)
+	archiveName, archiveUrls, archiveSha256, archiveStripPrefix := attrs.name, attrs.urls, attrs.sha256, attrs.stripPrefix
+
 	// Don't attempt to upgrade this dependency
 	if !strings.HasPrefix(archiveName, namePrefixFilter) {
 		return nil, nil
@@ -71,123 +172,208 @@ func Check(e *syntax.CallExpr, namePrefixFilter string, gitHubClient github.Clie
 	log.Printf("Checking %s", archiveName)
 
 	for _, url := range archiveUrls {
-		log.Println(url.Raw, gitHubReleaseRegex.MatchString(url.Raw), githubArchiveRegex.MatchString(url.Raw))
-		if gitHubReleaseRegex.MatchString(url.Raw) || githubArchiveRegex.MatchString(url.Raw) {
-			existingVersion, newerVersion, sha256sum, err := FindNewerGitHubRelease(gitHubClient, url.Raw)
+		if coordinate, release, err := sources.findNewerRelease(archiveName, url.Value.(string)); coordinate != "" {
+			upgrade := internal.Upgrade{
+				Rule:       "http_archive",
+				Name:       archiveName,
+				Coordinate: coordinate,
+				Source:     sources.source(url.Value.(string)),
+			}
+
+			if err == ErrNoNewerVersion {
+				upgrade.OldVersion = release.OldVersion
+				upgrade.NewVersion = release.OldVersion
+				return []internal.Upgrade{upgrade}, nil
+			}
 			if err != nil {
 				log.Println(err)
+				upgrade.Err = err
+				failed = append(failed, upgrade)
 				continue
 			}
 
+			upgrade.OldVersion = release.OldVersion
+			upgrade.NewVersion = release.NewVersion
+			upgrade.ReleaseNotes = release.Notes
+			upgrade.CompareURL = release.CompareURL
+
+			// All mirrors must serve the same archive as the one that was resolved
+			if err := verifyMirrors(sources.Download, archiveUrls, url, release); err != nil {
+				log.Println(err)
+				upgrade.Err = err
+				return []internal.Upgrade{upgrade}, nil
+			}
+
 			// Create replacements for all urls
 			for _, subUrl := range archiveUrls {
-				replacements = append(replacements, internal.LineReplacement{
-					Filename:     subUrl.TokenPos.Filename(),
-					Line:         subUrl.TokenPos.Line,
-					Find:         existingVersion,
-					Substitution: newerVersion,
-				})
+				if r, ok := literalReplacement(subUrl, rewriteURL(subUrl.Value.(string), release), release); ok {
+					upgrade.Replacements = append(upgrade.Replacements, r)
+				}
 			}
 
 			// Create substitution for sha256
 			if archiveSha256 != nil {
-				replacements = append(replacements, internal.LineReplacement{
+				upgrade.Replacements = append(upgrade.Replacements, internal.LineReplacement{
 					Filename:     archiveSha256.TokenPos.Filename(),
 					Line:         archiveSha256.TokenPos.Line,
 					Find:         archiveSha256.Value.(string),
-					Substitution: sha256sum,
+					Substitution: release.Sha256,
 				})
 			}
 
-			// Create substitution for strip_prefix
-			if archiveStripPrefix != nil {
-				replacements = append(replacements, internal.LineReplacement{
-					Filename:     archiveStripPrefix.TokenPos.Filename(),
-					Line:         archiveStripPrefix.TokenPos.Line,
-					Find:         existingVersion,
-					Substitution: newerVersion,
+			// Create substitution for integrity, using the same algorithm
+			if attrs.integrity != nil {
+				algorithm, _, err := integrity.Parse(attrs.integrity.Value.(string))
+				if err != nil {
+					log.Println(err)
+					upgrade.Err = err
+					upgrade.Replacements = nil
+					return []internal.Upgrade{upgrade}, nil
+				}
+				upgrade.Replacements = append(upgrade.Replacements, internal.LineReplacement{
+					Filename:     attrs.integrity.TokenPos.Filename(),
+					Line:         attrs.integrity.TokenPos.Line,
+					Find:         attrs.integrity.Value.(string),
+					Substitution: release.Integrity[algorithm],
 				})
 			}
+
+			// Create substitution for strip_prefix, the top-level directory might
+			// have been renamed in the new version
+			if archiveStripPrefix != nil {
+				stripPrefix := rewrite(archiveStripPrefix.Value.(string), release)
+				if release.StripPrefix != "" {
+					// Only the first path component is the top-level directory,
+					// keep any nested directories below it
+					stripPrefix = release.StripPrefix
+					if i := strings.Index(archiveStripPrefix.Value.(string), "/"); i >= 0 {
+						stripPrefix += archiveStripPrefix.Value.(string)[i:]
+					}
+				}
+				if r, ok := literalReplacement(archiveStripPrefix, stripPrefix, release); ok {
+					upgrade.Replacements = append(upgrade.Replacements, r)
+				}
+			}
+
+			return []internal.Upgrade{upgrade}, nil
 		}
 	}
 
-	if len(replacements) != 0 {
-		return replacements, nil
+	// Only report a failure if none of the mirrors could be resolved
+	if len(failed) != 0 {
+		return failed[:1], nil
 	}
 
 	return nil, errors.New("no match")
 }
 
-func FindNewerGitHubRelease(githubClient github.Client, url string) (oldVersion, newVersion, sha256sum string, err error) {
-	var owner, repo, tag, extension string
+// rewrite returns s with all occurrences of the old version replaced with the
+// new version, in the same form as they are written in s
+func rewrite(s string, release *Release) string {
+	return isemver.Rewrite(s, isemver.ParseTag(release.OldVersion), isemver.ParseTag(release.NewVersion))
+}
+
+// Matches the parts of GitHub and GitLab URLs that contain the full tag, as
+// opposed to a version that might be written differently
+var tagPositionRegex = regexp.MustCompile(`(/releases/download/|/archive/(?:refs/tags/)?|/-/archive/|/-/releases/)([^/]+?)(/|\.tar\.gz$|\.tgz$|\.tar\.xz$|\.tar\.bz2$|\.zip$)`)
 
-	if gitHubReleaseRegex.MatchString(url) {
-		submatches := gitHubReleaseRegex.FindStringSubmatch(url)
-		owner = submatches[1]
-		repo = submatches[2]
-		tag = submatches[3]
-		extension = "tar.gz"
-	} else if githubArchiveRegex.MatchString(url) {
-		submatches := githubArchiveRegex.FindStringSubmatch(url)
-		owner = submatches[1]
-		repo = submatches[2]
-		tag = submatches[3]
-		extension = "zip"
-	} else {
-		return "", "", "", errors.New("No pattern matches")
+// rewriteURL rewrites the version in a URL. Tag positions are replaced with the
+// new tag, and all other occurrences are rewritten in their own form. This
+// upgrades, for example, ".../download/1.2.3/tool-1.2.3.tar.gz" to
+// ".../download/v1.3.0/tool-1.3.0.tar.gz" if the tag of the new version is
+// "v1.3.0".
+func rewriteURL(url string, release *Release) string {
+	var sb strings.Builder
+	last := 0
+
+	for _, m := range tagPositionRegex.FindAllStringSubmatchIndex(url, -1) {
+		tagStart, tagEnd := m[4], m[5]
+		if !strings.EqualFold(url[tagStart:tagEnd], release.OldVersion) {
+			continue
+		}
+		sb.WriteString(rewrite(url[last:tagStart], release))
+		sb.WriteString(release.NewVersion)
+		last = tagEnd
 	}
 
-	oldVersion = tag
+	sb.WriteString(rewrite(url[last:], release))
+	return sb.String()
+}
 
-	releases, err := githubClient.ListReleases(owner, repo)
-	if err != nil {
-		return "", "", "", err
+// literalReplacement creates a replacement that changes the value of lit to
+// value. The replacement only covers the version if possible, and falls back
+// to replacing the full value.
+func literalReplacement(lit *syntax.Literal, value string, release *Release) (internal.LineReplacement, bool) {
+	oldValue := lit.Value.(string)
+	if oldValue == value {
+		return internal.LineReplacement{}, false
+	}
+
+	r := internal.LineReplacement{
+		Filename:     lit.TokenPos.Filename(),
+		Line:         lit.TokenPos.Line,
+		Find:         oldValue,
+		Substitution: value,
 	}
 
-	highestVersion, err := isemver.NormalizeNew(tag)
+	oldTag, newTag := isemver.ParseTag(release.OldVersion), isemver.ParseTag(release.NewVersion)
+	for _, pair := range [][2]string{{oldTag.Raw, newTag.Raw}, {oldTag.Version, newTag.Version}} {
+		if strings.Replace(oldValue, pair[0], pair[1], -1) == value {
+			r.Find, r.Substitution = pair[0], pair[1]
+			break
+		}
+	}
+
+	return r, true
+}
+
+// fetch downloads the new version of the archive from url, and sets the
+// checksums and the top-level directory of release
+func fetch(downloader *download.Downloader, url string, release *Release) error {
+	data, err := downloader.Get(url)
 	if err != nil {
-		return "", "", "", err
+		return err
 	}
 
-	var highestRelease *realGithub.RepositoryRelease
-	var highestReleaseTag string
+	release.Sha256 = fmt.Sprintf("%x", sha256.Sum256(data))
 
-	for _, release := range releases {
-		if ver, err := isemver.NormalizeNew(*release.TagName); err == nil {
-			if ver.GT(*highestVersion) {
-				highestVersion = ver
-				highestRelease = release
-				highestReleaseTag = *release.TagName
-			}
-		} else {
-			log.Println(err)
+	release.Integrity = make(map[string]string)
+	for _, algorithm := range []string{"sha256", "sha384", "sha512"} {
+		if release.Integrity[algorithm], err = integrity.Compute(algorithm, data); err != nil {
+			return err
 		}
 	}
 
-	if highestRelease != nil {
-		for _, r := range highestRelease.Assets {
-			if strings.HasSuffix(r.GetBrowserDownloadURL(), "."+extension) {
-				resp, err := http.Get(r.GetBrowserDownloadURL())
-				if err != nil {
-					panic(err)
-				}
-				defer resp.Body.Close()
+	release.StripPrefix, err = archive.TopLevelDir(url, data)
+	if err != nil {
+		log.Printf("Unable to list %s: %s", url, err)
+	}
 
-				allData, err := ioutil.ReadAll(resp.Body)
-				if err != nil {
-					panic(err)
-				}
+	return nil
+}
 
-				sha256sum = fmt.Sprintf("%x", sha256.Sum256(allData))
-				break
-			}
-		}
+// verifyMirrors downloads the new version from all urls, except for resolved
+// which was already downloaded when finding the release, and checks that they
+// all have the same checksum
+func verifyMirrors(downloader *download.Downloader, urls []*syntax.Literal, resolved *syntax.Literal, release *Release) error {
+	if release.Sha256 == "" {
+		return errors.New("unable to compute the sha256 of the new version")
 	}
 
-	if oldVersion == highestReleaseTag {
-		return "", "", "", errors.New("no newer version found")
+	for _, url := range urls {
+		if url == resolved {
+			continue
+		}
+
+		mirror := rewriteURL(url.Value.(string), release)
+		sha256sum, err := downloader.Sha256(mirror)
+		if err != nil {
+			return fmt.Errorf("failed to verify mirror: %w", err)
+		}
+		if sha256sum != release.Sha256 {
+			return fmt.Errorf("checksum mismatch for mirror %s: expected %s, got %s", mirror, release.Sha256, sha256sum)
+		}
 	}
 
-	log.Printf("Found: version=%s sha256=%s", highestReleaseTag, sha256sum)
-	return oldVersion, highestReleaseTag, sha256sum, nil
+	return nil
 }
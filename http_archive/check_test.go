@@ -0,0 +1,123 @@
+package http_archive
+
+import (
+	"archive/tar"
+	"bytes"
+	"compress/gzip"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"go.starlark.net/syntax"
+
+	"github.com/zegl/bazel_dependency_tools/internal"
+	"github.com/zegl/bazel_dependency_tools/internal/download"
+	"github.com/zegl/bazel_dependency_tools/internal/github"
+)
+
+func TestRewriteURL(t *testing.T) {
+	cases := []struct {
+		url, old, new, expected string
+	}{
+		{
+			"https://github.com/foo/tool/releases/download/v1.2.3/tool-1.2.3.tar.gz", "v1.2.3", "v1.3.0",
+			"https://github.com/foo/tool/releases/download/v1.3.0/tool-1.3.0.tar.gz",
+		},
+		{
+			"https://github.com/foo/tool/archive/V1.2.3.tar.gz", "v1.2.3", "v1.3.0",
+			"https://github.com/foo/tool/archive/v1.3.0.tar.gz",
+		},
+		{
+			"https://github.com/foo/tool/releases/download/1.2.3/tool-1.2.3.tar.gz", "1.2.3", "v1.3.0",
+			"https://github.com/foo/tool/releases/download/v1.3.0/tool-1.3.0.tar.gz",
+		},
+		{
+			"https://mirror.example.com/tool/Release-1.2.3/tool-v1.2.3.zip", "release-1.2.3", "release-1.3.0",
+			"https://mirror.example.com/tool/Release-1.3.0/tool-v1.3.0.zip",
+		},
+		{
+			"https://github.com/foo/tool/archive/v1.2.30.tar.gz", "v1.2.3", "v1.3.0",
+			"https://github.com/foo/tool/archive/v1.2.30.tar.gz",
+		},
+	}
+
+	for _, tc := range cases {
+		assert.Equal(t, tc.expected, rewriteURL(tc.url, &Release{OldVersion: tc.old, NewVersion: tc.new}), tc.url)
+	}
+}
+
+func TestRewriteStripPrefix(t *testing.T) {
+	release := &Release{OldVersion: "v1.2.3", NewVersion: "v1.3.0"}
+	assert.Equal(t, "tool-1.3.0", rewrite("tool-1.2.3", release))
+	assert.Equal(t, "tool-v1.3.0", rewrite("tool-v1.2.3", release))
+}
+
+func TestCheckDetectsStripPrefix(t *testing.T) {
+	// The top-level directory was renamed in the new version
+	var archive bytes.Buffer
+	gz := gzip.NewWriter(&archive)
+	tw := tar.NewWriter(gz)
+	assert.Nil(t, tw.WriteHeader(&tar.Header{Name: "tool-src-1.3.0/src/BUILD", Mode: 0644, Typeflag: tar.TypeReg}))
+	assert.Nil(t, tw.Close())
+	assert.Nil(t, gz.Close())
+
+	cases := []struct {
+		stripPrefix, expected string
+	}{
+		{"tool-1.2.0", "tool-src-1.3.0"},
+		{"tool-1.2.0/src", "tool-src-1.3.0/src"},
+	}
+
+	for _, tc := range cases {
+		client := github.NewFakeClient()
+		client.AddRelease("foo", "tool", "v1.3.0", "")
+
+		downloader := download.NewFakeDownloader(map[string][]byte{
+			"https://github.com/foo/tool/archive/v1.3.0.tar.gz": archive.Bytes(),
+		})
+
+		f, err := syntax.Parse("WORKSPACE", `http_archive(
+    name = "tool",
+    strip_prefix = "`+tc.stripPrefix+`",
+    urls = ["https://github.com/foo/tool/archive/v1.2.0.tar.gz"],
+)`, 0)
+		assert.Nil(t, err)
+
+		upgrades, err := Check(f.Stmts[0].(*syntax.ExprStmt).X.(*syntax.CallExpr), "", Sources{
+			GitHub:   github.Hosts{"github.com": client},
+			Download: downloader,
+		})
+		assert.Nil(t, err)
+		assert.Len(t, upgrades, 1)
+		assert.Equal(t, []internal.LineReplacement{
+			{Filename: "WORKSPACE", Line: 4, Find: "v1.2.0", Substitution: "v1.3.0"},
+			{Filename: "WORKSPACE", Line: 3, Find: tc.stripPrefix, Substitution: tc.expected},
+		}, upgrades[0].Replacements, tc.stripPrefix)
+	}
+}
+
+func TestCheckUpgradesIntegrity(t *testing.T) {
+	client := github.NewFakeClient()
+	client.AddRelease("foo", "tool", "v1.3.0", "")
+
+	downloader := download.NewFakeDownloader(map[string][]byte{
+		"https://github.com/foo/tool/archive/v1.3.0.tar.gz": []byte("tool-1.3.0"),
+	})
+
+	f, err := syntax.Parse("WORKSPACE", `http_archive(
+    name = "tool",
+    integrity = "sha384-LidFJ7oGdOqAAKJJPZlgGVEyAAMyFMlB4dsO1gm2QUDKR/v0VYr/j0uHykJ7FtaY",
+    urls = ["https://github.com/foo/tool/archive/v1.2.0.tar.gz"],
+)`, 0)
+	assert.Nil(t, err)
+
+	upgrades, err := Check(f.Stmts[0].(*syntax.ExprStmt).X.(*syntax.CallExpr), "", Sources{
+		GitHub:   github.Hosts{"github.com": client},
+		Download: downloader,
+	})
+	assert.Nil(t, err)
+	assert.Len(t, upgrades, 1)
+	assert.Equal(t, []internal.LineReplacement{
+		{Filename: "WORKSPACE", Line: 4, Find: "v1.2.0", Substitution: "v1.3.0"},
+		{Filename: "WORKSPACE", Line: 3, Find: "sha384-LidFJ7oGdOqAAKJJPZlgGVEyAAMyFMlB4dsO1gm2QUDKR/v0VYr/j0uHykJ7FtaY", Substitution: "sha384-znh6DfG1r1ARngQkagEiVLgvmbU+XaeYb4C412+Hjue35F28EqfVihoFdEcsENzA"},
+	}, upgrades[0].Replacements)
+}
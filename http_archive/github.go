@@ -0,0 +1,167 @@
+package http_archive
+
+import (
+	"errors"
+	"fmt"
+	"log"
+	"regexp"
+	"strings"
+
+	realGithub "github.com/google/go-github/v28/github"
+
+	"github.com/zegl/bazel_dependency_tools/internal/download"
+	"github.com/zegl/bazel_dependency_tools/internal/github"
+	isemver "github.com/zegl/bazel_dependency_tools/internal/semver"
+)
+
+// The host is matched loosely, only hosts that are configured as GitHub hosts are upgraded.
+
+// Matches release assets, eg. https://github.com/bazelbuild/rules_go/releases/download/v0.20.2/rules_go-v0.20.2.tar.gz
+var gitHubReleaseRegex = regexp.MustCompile(`^https://([a-zA-Z0-9\.:-]+)/([a-zA-Z0-9_\.-]+)/([a-zA-Z0-9_\.-]+)/releases/download/([a-zA-Z0-9_\.+-]+)/(.+?)\.(tar\.gz|tgz|tar\.xz|tar\.bz2|zip)$`)
+
+// Matches source archives of a tag or a commit, eg. https://github.com/bazelbuild/rules_sass/archive/1.15.2.zip
+// or https://github.com/bazelbuild/bazel-skylib/archive/refs/tags/1.0.2.tar.gz
+var githubArchiveRegex = regexp.MustCompile(`^https://([a-zA-Z0-9\.:-]+)/([a-zA-Z0-9_\.-]+)/([a-zA-Z0-9_\.-]+)/archive/(?:refs/tags/)?([a-zA-Z0-9_\.+-]+?)\.(tar\.gz|zip)$`)
+
+var commitRegex = regexp.MustCompile(`^[0-9a-f]{40}$`)
+
+// IsCommit returns true if the version of a GitHub archive or repository is a
+// commit, rather than a tag
+func IsCommit(version string) bool {
+	return commitRegex.MatchString(version)
+}
+
+// gitHubHost returns the host of a GitHub release or archive URL, or an empty
+// string if the URL is not in any of the known GitHub URL formats
+func gitHubHost(url string) string {
+	if submatches := gitHubReleaseRegex.FindStringSubmatch(url); submatches != nil {
+		return submatches[1]
+	}
+	if submatches := githubArchiveRegex.FindStringSubmatch(url); submatches != nil {
+		return submatches[1]
+	}
+	return ""
+}
+
+// gitHubCoordinate returns the "host/owner/repo" part of a GitHub URL
+func gitHubCoordinate(url string) string {
+	parts := strings.SplitN(strings.TrimPrefix(url, "https://"), "/", 4)
+	if len(parts) < 3 {
+		return url
+	}
+	return strings.Join(parts[:3], "/")
+}
+
+// gitHubArchiveRef returns the tag or commit of a GitHub source archive URL, or
+// an empty string if the URL is not a source archive
+func gitHubArchiveRef(url string) string {
+	if submatches := githubArchiveRegex.FindStringSubmatch(url); submatches != nil {
+		return submatches[4]
+	}
+	return ""
+}
+
+func FindNewerGitHubRelease(githubClient github.Client, downloader *download.Downloader, url string) (*Release, error) {
+	var host, owner, repo, tag string
+
+	if gitHubReleaseRegex.MatchString(url) {
+		submatches := gitHubReleaseRegex.FindStringSubmatch(url)
+		host = submatches[1]
+		owner = submatches[2]
+		repo = submatches[3]
+		tag = submatches[4]
+	} else if githubArchiveRegex.MatchString(url) {
+		submatches := githubArchiveRegex.FindStringSubmatch(url)
+		host = submatches[1]
+		owner = submatches[2]
+		repo = submatches[3]
+		tag = submatches[4]
+	} else {
+		return nil, errors.New("No pattern matches")
+	}
+
+	res := &Release{OldVersion: tag}
+
+	releases, err := githubClient.ListReleases(owner, repo)
+	if err != nil {
+		return nil, err
+	}
+
+	currentTag := isemver.ParseTag(tag)
+	currentVersion, err := currentTag.Semver()
+	if err != nil {
+		return nil, err
+	}
+	highestVersion := currentVersion
+
+	var highestRelease *realGithub.RepositoryRelease
+	var newerReleases []*realGithub.RepositoryRelease
+
+	for _, release := range releases {
+		releaseTag := isemver.ParseTag(release.GetTagName())
+		if !releaseTag.SamePrefix(currentTag) {
+			continue
+		}
+		if ver, err := releaseTag.Semver(); err == nil {
+			if ver.GT(*currentVersion) {
+				newerReleases = append(newerReleases, release)
+			}
+			if ver.GT(*highestVersion) {
+				highestVersion = ver
+				highestRelease = release
+			}
+		} else {
+			log.Println(err)
+		}
+	}
+
+	if highestRelease == nil {
+		return res, ErrNoNewerVersion
+	}
+
+	res.NewVersion = highestRelease.GetTagName()
+
+	// Source archives are not release assets, so the new version is always
+	// downloaded from the rewritten URL
+	if err := fetch(downloader, rewriteURL(url, res), res); err != nil {
+		return nil, err
+	}
+
+	res.Notes = releaseNotes(newerReleases)
+	res.CompareURL = fmt.Sprintf("https://%s/%s/%s/compare/%s...%s", host, owner, repo, res.OldVersion, res.NewVersion)
+
+	log.Printf("Found: version=%s sha256=%s", res.NewVersion, res.Sha256)
+	return res, nil
+}
+
+// FindNewerGitHubCommit finds the newest commit on branch for a GitHub source
+// archive that is pinned to a commit. An empty branch is the default branch.
+func FindNewerGitHubCommit(githubClient github.Client, downloader *download.Downloader, url, branch string) (*Release, error) {
+	submatches := githubArchiveRegex.FindStringSubmatch(url)
+	if submatches == nil || !commitRegex.MatchString(submatches[4]) {
+		return nil, errors.New("not a commit archive")
+	}
+	host, owner, repo, commit := submatches[1], submatches[2], submatches[3], submatches[4]
+
+	res := &Release{OldVersion: commit}
+
+	newest, err := githubClient.LatestCommit(owner, repo, branch)
+	if err != nil {
+		return nil, err
+	}
+
+	if newest == commit {
+		return res, ErrNoNewerVersion
+	}
+
+	res.NewVersion = newest
+
+	if err := fetch(downloader, rewriteURL(url, res), res); err != nil {
+		return nil, err
+	}
+
+	res.CompareURL = fmt.Sprintf("https://%s/%s/%s/compare/%s...%s", host, owner, repo, res.OldVersion, res.NewVersion)
+
+	log.Printf("Found: commit=%s sha256=%s", res.NewVersion, res.Sha256)
+	return res, nil
+}
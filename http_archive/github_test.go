@@ -0,0 +1,108 @@
+package http_archive
+
+import (
+	"crypto/sha256"
+	"fmt"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+
+	"github.com/zegl/bazel_dependency_tools/internal/download"
+	"github.com/zegl/bazel_dependency_tools/internal/github"
+)
+
+func TestGitHubURLs(t *testing.T) {
+	tests := []struct {
+		url        string
+		coordinate string
+		ref        string
+	}{
+		{"https://github.com/bazelbuild/rules_go/releases/download/v0.20.2/rules_go-v0.20.2.tar.gz", "github.com/bazelbuild/rules_go", ""},
+		{"https://github.com/bazelbuild/rules_sass/archive/1.15.2.zip", "github.com/bazelbuild/rules_sass", "1.15.2"},
+		{"https://github.com/bazelbuild/bazel-skylib/archive/v1.0.2.tar.gz", "github.com/bazelbuild/bazel-skylib", "v1.0.2"},
+		{"https://github.com/bazelbuild/bazel-skylib/archive/refs/tags/1.0.2.tar.gz", "github.com/bazelbuild/bazel-skylib", "1.0.2"},
+		{"https://github.com/grpc/grpc-java/archive/V1.25.0-RC1.tar.gz", "github.com/grpc/grpc-java", "V1.25.0-RC1"},
+		{"https://github.com/google/re2/archive/2019-12-01.tar.gz", "github.com/google/re2", "2019-12-01"},
+		{"https://github.com/bazelbuild/rules_docker/archive/d8f3ab8ca4a3b4e2a1a0e4a4c6e8a3b3e0a1e4c2.tar.gz", "github.com/bazelbuild/rules_docker", "d8f3ab8ca4a3b4e2a1a0e4a4c6e8a3b3e0a1e4c2"},
+	}
+
+	for _, tc := range tests {
+		assert.Equal(t, "github.com", gitHubHost(tc.url), tc.url)
+		assert.Equal(t, tc.coordinate, gitHubCoordinate(tc.url), tc.url)
+		assert.Equal(t, tc.ref, gitHubArchiveRef(tc.url), tc.url)
+	}
+
+	assert.Equal(t, "", gitHubHost("https://github.com/bazelbuild/rules_go/archive/refs/heads/master.tar.gz"))
+}
+
+func TestFindNewerGitHubCommit(t *testing.T) {
+	oldCommit := "d8f3ab8ca4a3b4e2a1a0e4a4c6e8a3b3e0a1e4c2"
+	newCommit := "0123456789abcdef0123456789abcdef01234567"
+
+	downloader := download.NewFakeDownloader(map[string][]byte{
+		"https://github.com/bazelbuild/rules_docker/archive/" + newCommit + ".tar.gz": []byte("archive"),
+	})
+
+	client := github.NewFakeClient()
+	client.AddCommit("bazelbuild", "rules_docker", "", newCommit)
+	client.AddCommit("bazelbuild", "rules_docker", "release-1.0", oldCommit)
+
+	url := "https://github.com/bazelbuild/rules_docker/archive/" + oldCommit + ".tar.gz"
+
+	release, err := FindNewerGitHubCommit(client, downloader, url, "")
+	assert.Nil(t, err)
+	assert.Equal(t, oldCommit, release.OldVersion)
+	assert.Equal(t, newCommit, release.NewVersion)
+	assert.Equal(t, fmt.Sprintf("%x", sha256.Sum256([]byte("archive"))), release.Sha256)
+	assert.Equal(t, "https://github.com/bazelbuild/rules_docker/compare/"+oldCommit+"..."+newCommit, release.CompareURL)
+
+	_, err = FindNewerGitHubCommit(client, downloader, url, "release-1.0")
+	assert.Equal(t, ErrNoNewerVersion, err)
+
+	// Commits are only followed if enabled
+	sources := Sources{GitHub: github.Hosts{"github.com": client}, Download: downloader}
+	_, _, err = sources.findNewerRelease("io_bazel_rules_docker", url)
+	assert.Equal(t, ErrNoNewerVersion, err)
+
+	sources.FollowCommits = true
+	_, release, err = sources.findNewerRelease("io_bazel_rules_docker", url)
+	assert.Nil(t, err)
+	assert.Equal(t, newCommit, release.NewVersion)
+}
+
+func TestCheckPrefixedTags(t *testing.T) {
+	tests := []struct {
+		oldTag, newTag string
+	}{
+		{"release-1.2.3", "release-1.3.0"},
+		{"V1.2.3", "v1.3.0"},
+		{"foo-1.2.3", "foo-1.3.0"},
+	}
+
+	for _, tc := range tests {
+		client := github.NewFakeClient()
+		client.AddRelease("foo", "tool", tc.newTag, "")
+		client.AddRelease("foo", "tool", tc.oldTag, "")
+		// Tags of other components in the same repository are ignored
+		client.AddRelease("foo", "tool", "bar-9.0.0", "")
+
+		newURL := "https://github.com/foo/tool/archive/" + tc.newTag + ".tar.gz"
+		downloader := download.NewFakeDownloader(map[string][]byte{
+			newURL: []byte(tc.newTag),
+		})
+
+		upgrades, err := Check(parseCall(t, `http_archive(
+    name = "tool",
+    urls = ["https://github.com/foo/tool/archive/`+tc.oldTag+`.tar.gz"],
+)`), "", Sources{
+			GitHub:   github.Hosts{"github.com": client},
+			Download: downloader,
+		})
+		assert.Nil(t, err, tc.oldTag)
+		if assert.Len(t, upgrades, 1, tc.oldTag) {
+			assert.Nil(t, upgrades[0].Err, tc.oldTag)
+			assert.Equal(t, tc.oldTag, upgrades[0].OldVersion)
+			assert.Equal(t, tc.newTag, upgrades[0].NewVersion)
+		}
+	}
+}
@@ -0,0 +1,111 @@
+package http_archive
+
+import (
+	"errors"
+	"fmt"
+	"log"
+	"regexp"
+	"sort"
+
+	"github.com/zegl/bazel_dependency_tools/internal/download"
+	"github.com/zegl/bazel_dependency_tools/internal/gitlab"
+	isemver "github.com/zegl/bazel_dependency_tools/internal/semver"
+)
+
+// Matches archives of a tag, eg. https://gitlab.com/group/project/-/archive/v1.2.3/project-v1.2.3.tar.gz
+var gitLabArchiveRegex = regexp.MustCompile(`https://gitlab\.com/([a-zA-Z0-9_\./-]+?)/-/archive/([a-zA-Z0-9_\.-]+)/[^/]+$`)
+
+// Matches permanent links to release assets, eg. https://gitlab.com/group/project/-/releases/v1.2.3/downloads/project.tar.gz
+var gitLabReleaseRegex = regexp.MustCompile(`https://gitlab\.com/([a-zA-Z0-9_\./-]+?)/-/releases/([a-zA-Z0-9_\.-]+)/downloads/.+$`)
+
+// gitLabProject returns the full path of the project of a GitLab archive or
+// release URL, or an empty string if the URL is not in any of the known
+// GitLab URL formats
+func gitLabProject(url string) string {
+	if submatches := gitLabArchiveRegex.FindStringSubmatch(url); submatches != nil {
+		return submatches[1]
+	}
+	if submatches := gitLabReleaseRegex.FindStringSubmatch(url); submatches != nil {
+		return submatches[1]
+	}
+	return ""
+}
+
+func FindNewerGitLabRelease(gitLabClient gitlab.Client, downloader *download.Downloader, url string) (*Release, error) {
+	var project, tag string
+
+	if submatches := gitLabArchiveRegex.FindStringSubmatch(url); submatches != nil {
+		project, tag = submatches[1], submatches[2]
+	} else if submatches := gitLabReleaseRegex.FindStringSubmatch(url); submatches != nil {
+		project, tag = submatches[1], submatches[2]
+	} else {
+		return nil, errors.New("No pattern matches")
+	}
+
+	res := &Release{OldVersion: tag}
+
+	releases, err := gitLabClient.ListReleases(project)
+	if err != nil {
+		return nil, err
+	}
+
+	currentTag := isemver.ParseTag(tag)
+	currentVersion, err := currentTag.Semver()
+	if err != nil {
+		return nil, err
+	}
+	highestVersion := currentVersion
+
+	var highestRelease *gitlab.Release
+	var newerReleases []gitlab.Release
+
+	for i, release := range releases {
+		releaseTag := isemver.ParseTag(release.TagName)
+		if !releaseTag.SamePrefix(currentTag) {
+			continue
+		}
+		if ver, err := releaseTag.Semver(); err == nil {
+			if ver.GT(*currentVersion) {
+				newerReleases = append(newerReleases, release)
+			}
+			if ver.GT(*highestVersion) {
+				highestVersion = ver
+				highestRelease = &releases[i]
+			}
+		} else {
+			log.Println(err)
+		}
+	}
+
+	if highestRelease == nil {
+		return res, ErrNoNewerVersion
+	}
+
+	res.NewVersion = highestRelease.TagName
+
+	if err := fetch(downloader, rewriteURL(url, res), res); err != nil {
+		return nil, err
+	}
+
+	res.Notes = gitLabReleaseNotes(newerReleases)
+	res.CompareURL = fmt.Sprintf("https://gitlab.com/%s/-/compare/%s...%s", project, res.OldVersion, res.NewVersion)
+
+	log.Printf("Found: version=%s sha256=%s", res.NewVersion, res.Sha256)
+	return res, nil
+}
+
+// gitLabReleaseNotes concatenates the notes of the releases, newest release
+// first, and truncated the same way as GitHub release notes.
+func gitLabReleaseNotes(releases []gitlab.Release) string {
+	sort.SliceStable(releases, func(i, j int) bool {
+		vi, _ := isemver.ParseTag(releases[i].TagName).Semver()
+		vj, _ := isemver.ParseTag(releases[j].TagName).Semver()
+		return vi.GT(*vj)
+	})
+
+	var sections []releaseNote
+	for _, r := range releases {
+		sections = append(sections, releaseNote{tag: r.TagName, url: r.Links.Self, body: r.Description})
+	}
+	return formatReleaseNotes(sections)
+}
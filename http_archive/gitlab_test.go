@@ -0,0 +1,36 @@
+package http_archive
+
+import (
+	"crypto/sha256"
+	"fmt"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+
+	"github.com/zegl/bazel_dependency_tools/internal/download"
+	"github.com/zegl/bazel_dependency_tools/internal/gitlab"
+)
+
+func TestFindNewerGitLabRelease(t *testing.T) {
+	downloader := download.NewFakeDownloader(map[string][]byte{
+		"https://gitlab.com/gitlab-org/gitlab-runner/-/archive/v12.6.0/gitlab-runner-v12.6.0.tar.gz": []byte("archive"),
+	})
+
+	client := gitlab.NewFakeClient()
+	client.AddRelease("gitlab-org/gitlab-runner", "v12.5.0", "")
+	client.AddRelease("gitlab-org/gitlab-runner", "v12.6.0", "Bug fixes")
+
+	release, err := FindNewerGitLabRelease(client, downloader, "https://gitlab.com/gitlab-org/gitlab-runner/-/archive/v12.4.0/gitlab-runner-v12.4.0.tar.gz")
+	assert.Nil(t, err)
+	assert.Equal(t, "v12.4.0", release.OldVersion)
+	assert.Equal(t, "v12.6.0", release.NewVersion)
+	assert.Equal(t, fmt.Sprintf("%x", sha256.Sum256([]byte("archive"))), release.Sha256)
+	assert.Equal(t, "https://gitlab.com/gitlab-org/gitlab-runner/-/compare/v12.4.0...v12.6.0", release.CompareURL)
+	assert.Equal(t, "### v12.6.0\n\nBug fixes\n\n### v12.5.0", release.Notes)
+}
+
+func TestGitLabProject(t *testing.T) {
+	assert.Equal(t, "gitlab-org/gitlab-runner", gitLabProject("https://gitlab.com/gitlab-org/gitlab-runner/-/archive/v12.4.0/gitlab-runner-v12.4.0.tar.gz"))
+	assert.Equal(t, "group/subgroup/project", gitLabProject("https://gitlab.com/group/subgroup/project/-/releases/1.0.0/downloads/project-1.0.0.zip"))
+	assert.Equal(t, "", gitLabProject("https://github.com/bazelbuild/rules_go/archive/0.19.3.zip"))
+}
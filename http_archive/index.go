@@ -0,0 +1,64 @@
+package http_archive
+
+import (
+	"log"
+	"net/url"
+	"strings"
+
+	"github.com/zegl/bazel_dependency_tools/internal/download"
+	"github.com/zegl/bazel_dependency_tools/internal/index"
+	isemver "github.com/zegl/bazel_dependency_tools/internal/semver"
+)
+
+// indexCoordinate returns the URL template of an archive without the scheme,
+// eg. "dl.example.org/tool/tool-{version}.tar.gz"
+func indexCoordinate(rawURL string) string {
+	template, _, err := index.Template(rawURL)
+	if err != nil {
+		return rawURL
+	}
+	if u, err := url.Parse(template); err == nil {
+		return u.Host + u.Path
+	}
+	return template
+}
+
+// FindNewerIndexRelease finds newer versions of an archive hosted on a plain
+// web server, by listing the available versions and substituting the newest
+// one into the URL.
+func FindNewerIndexRelease(host index.Host, downloader *download.Downloader, rawURL string) (*Release, error) {
+	template, version, err := index.Template(rawURL)
+	if err != nil {
+		return nil, err
+	}
+
+	res := &Release{OldVersion: version}
+
+	versions, err := host.Versions(downloader, template)
+	if err != nil {
+		return nil, err
+	}
+
+	highestVersion, err := isemver.ParseTag(version).Semver()
+	if err != nil {
+		return nil, err
+	}
+
+	for _, v := range versions {
+		if ver, err := isemver.ParseTag(v).Semver(); err == nil && ver.GT(*highestVersion) {
+			highestVersion = ver
+			res.NewVersion = v
+		}
+	}
+
+	if res.NewVersion == "" {
+		return res, ErrNoNewerVersion
+	}
+
+	if err := fetch(downloader, strings.Replace(template, index.Placeholder, res.NewVersion, -1), res); err != nil {
+		return nil, err
+	}
+
+	log.Printf("Found: version=%s sha256=%s", res.NewVersion, res.Sha256)
+	return res, nil
+}
@@ -0,0 +1,25 @@
+package http_archive
+
+import (
+	"crypto/sha256"
+	"fmt"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+
+	"github.com/zegl/bazel_dependency_tools/internal/download"
+	"github.com/zegl/bazel_dependency_tools/internal/index"
+)
+
+func TestFindNewerIndexRelease(t *testing.T) {
+	downloader := download.NewFakeDownloader(map[string][]byte{
+		"https://dl.example.org/tool/":                   []byte(`<a href="tool-1.2.3.tar.gz">tool-1.2.3.tar.gz</a><a href="tool-1.10.0.tar.gz">tool-1.10.0.tar.gz</a><a href="tool-1.9.0.tar.gz">tool-1.9.0.tar.gz</a>`),
+		"https://dl.example.org/tool/tool-1.10.0.tar.gz": []byte("archive"),
+	})
+
+	release, err := FindNewerIndexRelease(index.Host{}, downloader, "https://dl.example.org/tool/tool-1.2.3.tar.gz")
+	assert.Nil(t, err)
+	assert.Equal(t, "1.2.3", release.OldVersion)
+	assert.Equal(t, "1.10.0", release.NewVersion)
+	assert.Equal(t, fmt.Sprintf("%x", sha256.Sum256([]byte("archive"))), release.Sha256)
+}
@@ -0,0 +1,59 @@
+package http_archive
+
+import (
+	"errors"
+	"fmt"
+	"strings"
+
+	"go.starlark.net/syntax"
+
+	"github.com/zegl/bazel_dependency_tools/internal"
+	"github.com/zegl/bazel_dependency_tools/internal/integrity"
+)
+
+// MigrateIntegrity returns the replacements that convert the hex encoded
+// sha256 attribute to an integrity attribute in the Subresource Integrity
+// format, eg. "sha256-<base64>"
+func MigrateIntegrity(e *syntax.CallExpr, namePrefixFilter string) ([]internal.LineReplacement, error) {
+	attrs := parseAttributes(e)
+
+	// Don't migrate this dependency
+	if !strings.HasPrefix(attrs.name, namePrefixFilter) {
+		return nil, nil
+	}
+
+	if attrs.sha256 == nil {
+		return nil, nil
+	}
+	if attrs.integrity != nil {
+		return nil, fmt.Errorf("%s has both sha256 and integrity", attrs.name)
+	}
+
+	sri, err := integrity.FromHexSha256(attrs.sha256.Value.(string))
+	if err != nil {
+		return nil, fmt.Errorf("%s: %w", attrs.name, err)
+	}
+
+	line, err := sourceLine(attrs.sha256.TokenPos)
+	if err != nil {
+		return nil, err
+	}
+
+	// Replace the full argument, from the attribute name to the value
+	end := strings.Index(line, attrs.sha256.Raw)
+	if end < 0 {
+		return nil, errors.New("unable to find " + attrs.sha256.Raw + " in the source")
+	}
+	start := strings.LastIndex(line[:end], "sha256")
+	if start < 0 {
+		return nil, errors.New("unable to find the sha256 attribute in the source")
+	}
+	end += len(attrs.sha256.Raw)
+
+	return []internal.LineReplacement{{
+		Filename:     attrs.sha256.TokenPos.Filename(),
+		Line:         attrs.sha256.TokenPos.Line,
+		Find:         line[start:end],
+		Substitution: fmt.Sprintf("integrity = %q", sri),
+	}}, nil
+}
@@ -0,0 +1,242 @@
+package http_archive
+
+import (
+	"errors"
+	"fmt"
+	"log"
+	"path"
+	"regexp"
+	"sort"
+	"strings"
+
+	"go.starlark.net/syntax"
+
+	"github.com/zegl/bazel_dependency_tools/internal/archive"
+	"github.com/zegl/bazel_dependency_tools/internal/download"
+	"github.com/zegl/bazel_dependency_tools/internal/github"
+	"github.com/zegl/bazel_dependency_tools/internal/spdx"
+)
+
+// Sources of an ArchiveLicense
+const (
+	LicenseSourceFile      = "LICENSE file"
+	LicenseSourceGitHubAPI = "GitHub API"
+)
+
+// ArchiveLicense is the license of an archive
+type ArchiveLicense struct {
+	SPDX   string // The license as an SPDX expression, empty if it's unknown
+	Source string // How the license was found, eg. LicenseSourceFile
+
+	// Files are the license and notice files in the root of the archive, by
+	// their path relative to the root
+	Files map[string][]byte
+}
+
+// Matches LICENSE, COPYING and NOTICE files, with or without an extension or
+// suffix, eg. "LICENSE.txt" or "LICENSE-MIT"
+var licenseFileRegex = regexp.MustCompile(`(?i)^(licen[cs]e|copying|notice)([-._].*)?$`)
+
+// alternativeLicenseFileRegex matches license files named after their license,
+// such as LICENSE-APACHE and LICENSE-MIT.txt
+var alternativeLicenseFileRegex = regexp.MustCompile(`(?i)^licen[cs]e-[^.]+(\.(md|txt))?$`)
+
+// License finds the license of an http_archive, by classifying the license
+// files in the archive. If the archive doesn't contain a known license, the
+// license is looked up with the GitHub API for archives hosted on GitHub. The
+// license is nil if the archive was skipped.
+func License(e *syntax.CallExpr, namePrefixFilter string, downloader *download.Downloader, gitHub github.Hosts) (string, *ArchiveLicense, error) {
+	attrs := parseAttributes(e)
+
+	// Don't check this dependency
+	if !strings.HasPrefix(attrs.name, namePrefixFilter) {
+		return "", nil, nil
+	}
+
+	if len(attrs.urls) == 0 {
+		return attrs.name, nil, fmt.Errorf("unable to parse %s", attrs.name)
+	}
+
+	var sha256sum, stripPrefix string
+	if attrs.sha256 != nil {
+		sha256sum = attrs.sha256.Value.(string)
+	}
+	if attrs.stripPrefix != nil {
+		stripPrefix = attrs.stripPrefix.Value.(string)
+	}
+
+	var urls []string
+	for _, url := range attrs.urls {
+		urls = append(urls, url.Value.(string))
+	}
+
+	license, err := archiveLicense(urls, sha256sum, stripPrefix, false, downloader, gitHub)
+	return attrs.name, license, err
+}
+
+// GitRepositoryLicense finds the license of a git_repository that is hosted
+// on GitHub, by downloading a source archive of the commit or tag
+func GitRepositoryLicense(e *syntax.CallExpr, namePrefixFilter string, downloader *download.Downloader, gitHub github.Hosts) (string, *ArchiveLicense, error) {
+	repo := parseGitRepository(e)
+
+	// Don't check this dependency
+	if !strings.HasPrefix(repo.name, namePrefixFilter) {
+		return "", nil, nil
+	}
+
+	coordinate := gitRemoteCoordinate(repo.remote)
+	host := strings.SplitN(coordinate, "/", 2)[0]
+	if _, ok := gitHub.Match(host); !ok || repo.ref == "" {
+		return repo.name, nil, fmt.Errorf("unable to find the license of %s: only GitHub repositories are supported", repo.name)
+	}
+
+	url := fmt.Sprintf("https://%s/archive/%s.tar.gz", coordinate, repo.ref)
+	license, err := archiveLicense([]string{url}, "", "", true, downloader, gitHub)
+	return repo.name, license, err
+}
+
+// gitRepository are the attributes of a git_repository
+type gitRepository struct {
+	name, remote string
+	ref          string // The commit or the tag
+}
+
+func parseGitRepository(e *syntax.CallExpr) gitRepository {
+	var res gitRepository
+	for _, arg := range e.Args {
+		if binExp, ok := arg.(*syntax.BinaryExpr); ok && binExp.Op == syntax.EQ {
+			if xIdent, ok := binExp.X.(*syntax.Ident); ok {
+				rhs, ok := binExp.Y.(*syntax.Literal)
+				if !ok {
+					continue
+				}
+				switch xIdent.Name {
+				case "name":
+					res.name = rhs.Value.(string)
+				case "remote":
+					res.remote = rhs.Value.(string)
+				case "commit", "tag":
+					res.ref = rhs.Value.(string)
+				}
+			}
+		}
+	}
+	return res
+}
+
+// gitRemoteCoordinate converts a git remote, eg. "https://github.com/owner/repo.git"
+// or "git@github.com:owner/repo.git", to "github.com/owner/repo"
+func gitRemoteCoordinate(remote string) string {
+	remote = strings.TrimSuffix(strings.TrimSuffix(remote, "/"), ".git")
+	if strings.HasPrefix(remote, "git@") {
+		return strings.Replace(strings.TrimPrefix(remote, "git@"), ":", "/", 1)
+	}
+	for _, prefix := range []string{"https://", "http://", "ssh://git@", "git://"} {
+		remote = strings.TrimPrefix(remote, prefix)
+	}
+	return remote
+}
+
+// archiveLicense downloads the archive from the first url that works, and
+// classifies its license files. With detectPrefix, the top-level directory of
+// the archive is used as stripPrefix.
+func archiveLicense(urls []string, sha256sum, stripPrefix string, detectPrefix bool, downloader *download.Downloader, gitHub github.Hosts) (*ArchiveLicense, error) {
+	var data []byte
+	var url string
+	var err error
+	for _, url = range urls {
+		if data, err = downloader.GetSha256(url, sha256sum); err == nil {
+			break
+		}
+		log.Println(err)
+	}
+
+	res := &ArchiveLicense{}
+
+	if err == nil {
+		if detectPrefix {
+			stripPrefix, _ = archive.TopLevelDir(url, data)
+		}
+
+		res.Files, err = archive.ReadFiles(url, data, func(p string) bool {
+			p = strings.TrimPrefix(path.Clean("/"+p), "/")
+			if stripPrefix != "" {
+				if !strings.HasPrefix(p, stripPrefix+"/") {
+					return false
+				}
+				p = strings.TrimPrefix(p, stripPrefix+"/")
+			}
+			return !strings.Contains(p, "/") && licenseFileRegex.MatchString(p)
+		})
+		if err != nil {
+			log.Println(err)
+		}
+
+		// Use paths relative to the root of the repository
+		files := make(map[string][]byte)
+		for p, content := range res.Files {
+			files[path.Base(p)] = content
+		}
+		res.Files = files
+
+		res.SPDX = classifyLicenseFiles(res.Files)
+		if res.SPDX != "" {
+			res.Source = LicenseSourceFile
+			return res, nil
+		}
+	}
+
+	// Fall back to the license that GitHub has detected
+	for _, url := range urls {
+		if client, ok := gitHub.Match(gitHubHost(url)); ok {
+			parts := strings.Split(gitHubCoordinate(url), "/")
+			if id, err := client.License(parts[1], parts[2]); err == nil {
+				res.SPDX = id
+				res.Source = LicenseSourceGitHubAPI
+				return res, nil
+			}
+		}
+	}
+
+	if err != nil {
+		return nil, err
+	}
+	return res, errors.New("no license found")
+}
+
+// classifyLicenseFiles returns the licenses of the files as an SPDX
+// expression. Files named after a license (LICENSE-APACHE, LICENSE-MIT, ...)
+// are alternatives that the user can choose between, all other license files
+// apply together.
+func classifyLicenseFiles(files map[string][]byte) string {
+	seen := make(map[string]bool)
+	var all, alternatives []string
+	for name, content := range files {
+		if strings.HasPrefix(strings.ToLower(name), "notice") {
+			continue
+		}
+		id, ok := spdx.Classify(string(content))
+		if !ok || seen[id] {
+			continue
+		}
+		seen[id] = true
+		if alternativeLicenseFileRegex.MatchString(name) {
+			alternatives = append(alternatives, id)
+		} else {
+			all = append(all, id)
+		}
+	}
+
+	sort.Strings(alternatives)
+	if len(alternatives) > 1 {
+		or := strings.Join(alternatives, " OR ")
+		if len(all) == 0 {
+			return or
+		}
+		alternatives = []string{"(" + or + ")"}
+	}
+
+	all = append(all, alternatives...)
+	sort.Strings(all)
+	return strings.Join(all, " AND ")
+}
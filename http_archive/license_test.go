@@ -0,0 +1,138 @@
+package http_archive
+
+import (
+	"archive/tar"
+	"bytes"
+	"compress/gzip"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"go.starlark.net/syntax"
+
+	"github.com/zegl/bazel_dependency_tools/internal/download"
+	"github.com/zegl/bazel_dependency_tools/internal/github"
+)
+
+const apacheHeader = `Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+`
+
+// tarGz creates a tar.gz archive with the files
+func tarGz(t *testing.T, files map[string]string) []byte {
+	var archive bytes.Buffer
+	gz := gzip.NewWriter(&archive)
+	tw := tar.NewWriter(gz)
+	for name, content := range files {
+		assert.Nil(t, tw.WriteHeader(&tar.Header{Name: name, Mode: 0644, Size: int64(len(content)), Typeflag: tar.TypeReg}))
+		_, err := tw.Write([]byte(content))
+		assert.Nil(t, err)
+	}
+	assert.Nil(t, tw.Close())
+	assert.Nil(t, gz.Close())
+	return archive.Bytes()
+}
+
+func parseCall(t *testing.T, src string) *syntax.CallExpr {
+	f, err := syntax.Parse("WORKSPACE", src, 0)
+	assert.Nil(t, err)
+	return f.Stmts[0].(*syntax.ExprStmt).X.(*syntax.CallExpr)
+}
+
+func TestLicenseFromFile(t *testing.T) {
+	downloader := download.NewFakeDownloader(map[string][]byte{
+		"https://example.com/tool-1.2.0.tar.gz": tarGz(t, map[string]string{
+			"tool-1.2.0/LICENSE.txt":    apacheHeader,
+			"tool-1.2.0/NOTICE":         "Tool\nCopyright 2019 Example\n",
+			"tool-1.2.0/vendor/LICENSE": "GNU GENERAL PUBLIC LICENSE",
+			"tool-1.2.0/src/license.go": "package src",
+			"tool-1.2.0/README.md":      "# Tool",
+		}),
+	})
+
+	name, license, err := License(parseCall(t, `http_archive(
+    name = "tool",
+    strip_prefix = "tool-1.2.0",
+    urls = ["https://example.com/tool-1.2.0.tar.gz"],
+)`), "", downloader, github.Hosts{})
+	assert.Nil(t, err)
+	assert.Equal(t, "tool", name)
+	assert.Equal(t, "Apache-2.0", license.SPDX)
+	assert.Equal(t, LicenseSourceFile, license.Source)
+	assert.Equal(t, map[string][]byte{
+		"LICENSE.txt": []byte(apacheHeader),
+		"NOTICE":      []byte("Tool\nCopyright 2019 Example\n"),
+	}, license.Files)
+}
+
+func TestClassifyLicenseFiles(t *testing.T) {
+	mit := []byte(`Permission is hereby granted, free of charge, to any person obtaining a copy
+of this software and associated documentation files (the "Software"), to deal
+in the Software without restriction, including without limitation the rights
+to use, copy, modify, merge, publish, distribute, sublicense, and/or sell
+copies of the Software, and to permit persons to whom the Software is
+furnished to do so, subject to the following conditions:
+
+The above copyright notice and this permission notice shall be included in all
+copies or substantial portions of the Software.
+`)
+
+	// Dual licensed, the user can choose either license
+	assert.Equal(t, "Apache-2.0 OR MIT", classifyLicenseFiles(map[string][]byte{
+		"LICENSE-APACHE": []byte(apacheHeader),
+		"LICENSE-MIT":    mit,
+	}))
+
+	// Files that are not alternatives all apply
+	assert.Equal(t, "Apache-2.0 AND MIT", classifyLicenseFiles(map[string][]byte{
+		"LICENSE": []byte(apacheHeader),
+		"COPYING": mit,
+	}))
+	assert.Equal(t, "(Apache-2.0 OR MIT) AND GPL-2.0-only", classifyLicenseFiles(map[string][]byte{
+		"LICENSE-APACHE.txt": []byte(apacheHeader),
+		"LICENSE-MIT":        mit,
+		"COPYING":            []byte("GNU GENERAL PUBLIC LICENSE\nVersion 2, June 1991"),
+	}))
+}
+
+func TestLicenseFromGitHubAPI(t *testing.T) {
+	client := github.NewFakeClient()
+	client.AddLicense("foo", "tool", "MIT")
+
+	downloader := download.NewFakeDownloader(map[string][]byte{
+		"https://github.com/foo/tool/archive/v1.2.0.tar.gz": tarGz(t, map[string]string{
+			"tool-1.2.0/README.md": "# Tool",
+		}),
+	})
+
+	_, license, err := License(parseCall(t, `http_archive(
+    name = "tool",
+    urls = ["https://github.com/foo/tool/archive/v1.2.0.tar.gz"],
+)`), "", downloader, github.Hosts{"github.com": client})
+	assert.Nil(t, err)
+	assert.Equal(t, "MIT", license.SPDX)
+	assert.Equal(t, LicenseSourceGitHubAPI, license.Source)
+}
+
+func TestGitRepositoryLicense(t *testing.T) {
+	downloader := download.NewFakeDownloader(map[string][]byte{
+		"https://github.com/foo/tool/archive/0123abc.tar.gz": tarGz(t, map[string]string{
+			"tool-0123abc/LICENSE": apacheHeader,
+		}),
+	})
+
+	name, license, err := GitRepositoryLicense(parseCall(t, `git_repository(
+    name = "tool",
+    remote = "git@github.com:foo/tool.git",
+    commit = "0123abc",
+)`), "", downloader, github.Hosts{"github.com": github.NewFakeClient()})
+	assert.Nil(t, err)
+	assert.Equal(t, "tool", name)
+	assert.Equal(t, "Apache-2.0", license.SPDX)
+
+	_, _, err = GitRepositoryLicense(parseCall(t, `git_repository(
+    name = "other",
+    remote = "https://gitlab.com/foo/other.git",
+    tag = "v1.0.0",
+)`), "", downloader, github.Hosts{"github.com": github.NewFakeClient()})
+	assert.NotNil(t, err)
+}
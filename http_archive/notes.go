@@ -0,0 +1,87 @@
+package http_archive
+
+import (
+	"fmt"
+	"sort"
+	"strings"
+	"unicode/utf8"
+
+	realGithub "github.com/google/go-github/v28/github"
+
+	isemver "github.com/zegl/bazel_dependency_tools/internal/semver"
+)
+
+const (
+	// maxReleaseNotesLength is the maximum length of the notes of a single release
+	maxReleaseNotesLength = 1000
+
+	// maxTotalNotesLength is the maximum length of the notes of all releases combined
+	maxTotalNotesLength = 5000
+)
+
+type releaseNote struct {
+	tag, url, body string
+}
+
+// releaseNotes concatenates the notes of the releases, newest release first.
+// The notes of each release, and the combined notes, are truncated to keep the
+// output readable.
+func releaseNotes(releases []*realGithub.RepositoryRelease) string {
+	sorted := make([]*realGithub.RepositoryRelease, len(releases))
+	copy(sorted, releases)
+	sort.SliceStable(sorted, func(i, j int) bool {
+		vi, _ := isemver.ParseTag(sorted[i].GetTagName()).Semver()
+		vj, _ := isemver.ParseTag(sorted[j].GetTagName()).Semver()
+		return vi.GT(*vj)
+	})
+
+	var notes []releaseNote
+	for _, release := range sorted {
+		notes = append(notes, releaseNote{tag: release.GetTagName(), url: release.GetHTMLURL(), body: release.GetBody()})
+	}
+	return formatReleaseNotes(notes)
+}
+
+func formatReleaseNotes(notes []releaseNote) string {
+	var sb strings.Builder
+	for i, note := range notes {
+		var section strings.Builder
+		if note.url != "" {
+			fmt.Fprintf(&section, "### [%s](%s)\n\n", note.tag, note.url)
+		} else {
+			fmt.Fprintf(&section, "### %s\n\n", note.tag)
+		}
+		if body := strings.TrimSpace(note.body); body != "" {
+			section.WriteString(truncate(body, maxReleaseNotesLength))
+			section.WriteString("\n\n")
+		}
+
+		if sb.Len()+section.Len() > maxTotalNotesLength {
+			fmt.Fprintf(&sb, "... and %d more releases\n", len(notes)-i)
+			break
+		}
+		sb.WriteString(section.String())
+	}
+
+	return strings.TrimSpace(sb.String())
+}
+
+// truncate shortens s to at most max bytes, cutting at the last line break if
+// possible
+func truncate(s string, max int) string {
+	if len(s) <= max {
+		return s
+	}
+
+	// Don't cut in the middle of a multi-byte character
+	for max > 0 && !utf8.RuneStart(s[max]) {
+		max--
+	}
+
+	cut := s[:max]
+	if idx := strings.LastIndex(cut, "\n"); idx > 0 {
+		cut = cut[:idx]
+	}
+
+	return strings.TrimSpace(cut) + "\n..."
+}
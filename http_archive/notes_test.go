@@ -0,0 +1,41 @@
+package http_archive
+
+import (
+	"strings"
+	"testing"
+
+	realGithub "github.com/google/go-github/v28/github"
+	"github.com/stretchr/testify/assert"
+)
+
+func release(tag, body string) *realGithub.RepositoryRelease {
+	htmlURL := "https://github.com/bazelbuild/rules_go/releases/tag/" + tag
+	return &realGithub.RepositoryRelease{TagName: &tag, Body: &body, HTMLURL: &htmlURL}
+}
+
+func TestReleaseNotes(t *testing.T) {
+	notes := releaseNotes([]*realGithub.RepositoryRelease{
+		release("0.19.4", "Fixed a bug"),
+		release("0.19.5", "Fixed another bug\r\n"),
+	})
+
+	assert.Equal(t, "### [0.19.5](https://github.com/bazelbuild/rules_go/releases/tag/0.19.5)\n\n"+
+		"Fixed another bug\n\n"+
+		"### [0.19.4](https://github.com/bazelbuild/rules_go/releases/tag/0.19.4)\n\n"+
+		"Fixed a bug", notes)
+}
+
+func TestReleaseNotesTruncated(t *testing.T) {
+	long := strings.Repeat("* Change\n", 200)
+
+	var releases []*realGithub.RepositoryRelease
+	for _, tag := range []string{"1.1.0", "1.2.0", "1.3.0", "1.4.0", "1.5.0", "1.6.0", "1.7.0"} {
+		releases = append(releases, release(tag, long))
+	}
+
+	notes := releaseNotes(releases)
+	assert.True(t, len(notes) <= maxTotalNotesLength+100)
+	assert.True(t, strings.HasPrefix(notes, "### [1.7.0]"))
+	assert.Contains(t, notes, "* Change\n...")
+	assert.True(t, strings.HasSuffix(notes, "... and 3 more releases"))
+}
@@ -0,0 +1,106 @@
+package http_archive
+
+import (
+	"errors"
+	"fmt"
+	"io/ioutil"
+	"log"
+	"strings"
+
+	"go.starlark.net/syntax"
+
+	"github.com/zegl/bazel_dependency_tools/internal"
+	"github.com/zegl/bazel_dependency_tools/internal/download"
+)
+
+// Pin downloads archives that don't declare a checksum, and returns the
+// replacements that add a sha256 attribute after the name of the archive
+func Pin(e *syntax.CallExpr, namePrefixFilter string, downloader *download.Downloader) ([]internal.LineReplacement, error) {
+	attrs := parseAttributes(e)
+
+	// Don't pin this dependency
+	if !strings.HasPrefix(attrs.name, namePrefixFilter) {
+		return nil, nil
+	}
+
+	// Already pinned
+	if attrs.sha256 != nil || attrs.integrity != nil {
+		return nil, nil
+	}
+
+	if attrs.nameLiteral == nil || len(attrs.urls) == 0 {
+		return nil, fmt.Errorf("unable to parse %s", attrs.name)
+	}
+
+	log.Printf("Pinning %s", attrs.name)
+
+	var sha256sum string
+	for _, url := range attrs.urls {
+		s, err := downloader.Sha256(url.Value.(string))
+		if err != nil {
+			return nil, err
+		}
+		if sha256sum != "" && s != sha256sum {
+			return nil, fmt.Errorf("checksum mismatch for mirror %s: expected %s, got %s", url.Value.(string), sha256sum, s)
+		}
+		sha256sum = s
+	}
+
+	r, err := insertAfter(attrs.nameLiteral, fmt.Sprintf("sha256 = %q", sha256sum))
+	if err != nil {
+		return nil, err
+	}
+
+	return []internal.LineReplacement{r}, nil
+}
+
+// insertAfter creates a replacement that inserts the argument arg after the
+// argument with the value lit. If lit is on a line of its own, arg is added on
+// a new line with the same indentation, otherwise it's added on the same line.
+func insertAfter(lit *syntax.Literal, arg string) (internal.LineReplacement, error) {
+	line, err := sourceLine(lit.TokenPos)
+	if err != nil {
+		return internal.LineReplacement{}, err
+	}
+
+	idx := strings.Index(line, lit.Raw)
+	if idx < 0 {
+		return internal.LineReplacement{}, errors.New("unable to find " + lit.Raw + " in the source")
+	}
+
+	r := internal.LineReplacement{
+		Filename: lit.TokenPos.Filename(),
+		Line:     lit.TokenPos.Line,
+		Find:     lit.Raw,
+	}
+
+	indent := line[:len(line)-len(strings.TrimLeft(line, " \t"))]
+	ownLine := !strings.Contains(line[:idx], "(")
+
+	switch {
+	case ownLine && strings.HasPrefix(line[idx+len(lit.Raw):], ","):
+		r.Find += ","
+		r.Substitution = lit.Raw + ",\n" + indent + arg + ","
+	case ownLine:
+		r.Substitution = lit.Raw + ",\n" + indent + arg
+	default:
+		r.Substitution = lit.Raw + ", " + arg
+	}
+
+	return r, nil
+}
+
+// sourceLine returns the line of the source file at pos
+func sourceLine(pos syntax.Position) (string, error) {
+	content, err := ioutil.ReadFile(pos.Filename())
+	if err != nil {
+		return "", err
+	}
+
+	lines := strings.Split(string(content), "\n")
+	if pos.Line < 1 || int(pos.Line) > len(lines) {
+		return "", fmt.Errorf("%s has no line %d", pos.Filename(), pos.Line)
+	}
+
+	return lines[pos.Line-1], nil
+}
@@ -0,0 +1,106 @@
+package http_archive
+
+import (
+	"encoding/hex"
+	"fmt"
+	"strings"
+
+	"go.starlark.net/syntax"
+
+	"github.com/zegl/bazel_dependency_tools/internal/integrity"
+	"github.com/zegl/bazel_dependency_tools/internal/sbom"
+)
+
+// Package describes an http_archive, http_file or http_jar for an SBOM.
+// Archives from github.com get a GitHub purl, all others get a generic purl
+// with the download URL. The license is not set, and the package is nil if it
+// was skipped.
+func Package(e *syntax.CallExpr, rule, namePrefixFilter string) (*sbom.Package, error) {
+	attrs := parseAttributes(e)
+
+	// Don't check this dependency
+	if !strings.HasPrefix(attrs.name, namePrefixFilter) {
+		return nil, nil
+	}
+
+	if len(attrs.urls) == 0 {
+		return nil, fmt.Errorf("unable to parse %s", attrs.name)
+	}
+
+	p := &sbom.Package{
+		Rule: rule,
+		Name: attrs.name,
+		URL:  attrs.urls[0].Value.(string),
+	}
+
+	if attrs.sha256 != nil {
+		p.Hashes = append(p.Hashes, sbom.Hash{Algorithm: sbom.SHA256, Value: attrs.sha256.Value.(string)})
+	}
+	if attrs.integrity != nil {
+		algorithm, digest, err := integrity.Parse(attrs.integrity.Value.(string))
+		if err != nil {
+			return nil, err
+		}
+		h := sbom.Hash{Algorithm: strings.ToUpper(algorithm), Value: hex.EncodeToString(digest)}
+		if len(p.Hashes) == 0 || p.Hashes[0] != h {
+			p.Hashes = append(p.Hashes, h)
+		}
+	}
+
+	for _, url := range attrs.urls {
+		url := url.Value.(string)
+		if gitHubHost(url) != "github.com" {
+			continue
+		}
+		parts := strings.Split(gitHubCoordinate(url), "/")
+		p.Version = urlVersion(url)
+		p.PURL = sbom.PURL("github", parts[1]+"/"+parts[2], p.Version, nil)
+		return p, nil
+	}
+
+	p.Version = urlVersion(p.URL)
+	p.PURL = sbom.PURL("generic", attrs.name, p.Version, map[string]string{"download_url": p.URL})
+	return p, nil
+}
+
+// urlVersion returns the tag or commit in a release or archive URL, or an
+// empty string if the URL is not in a known format
+func urlVersion(url string) string {
+	if m := tagPositionRegex.FindStringSubmatch(url); m != nil {
+		return m[2]
+	}
+	return ""
+}
+
+// GitRepositoryPackage describes a git_repository for an SBOM. The license is
+// not set, and the package is nil if it was skipped.
+func GitRepositoryPackage(e *syntax.CallExpr, namePrefixFilter string) (*sbom.Package, error) {
+	repo := parseGitRepository(e)
+
+	// Don't check this dependency
+	if !strings.HasPrefix(repo.name, namePrefixFilter) {
+		return nil, nil
+	}
+
+	if repo.remote == "" {
+		return nil, fmt.Errorf("unable to parse %s", repo.name)
+	}
+
+	p := &sbom.Package{
+		Rule:    "git_repository",
+		Name:    repo.name,
+		Version: repo.ref,
+		URL:     "git+" + repo.remote,
+	}
+	if repo.ref != "" {
+		p.URL += "@" + repo.ref
+	}
+
+	if parts := strings.Split(gitRemoteCoordinate(repo.remote), "/"); len(parts) == 3 && parts[0] == "github.com" {
+		p.PURL = sbom.PURL("github", parts[1]+"/"+parts[2], repo.ref, nil)
+	} else {
+		p.PURL = sbom.PURL("generic", repo.name, repo.ref, map[string]string{"vcs_url": p.URL})
+	}
+
+	return p, nil
+}
@@ -0,0 +1,82 @@
+package http_archive
+
+import (
+	"crypto/sha256"
+	"errors"
+	"fmt"
+	"log"
+	"strings"
+
+	"go.starlark.net/syntax"
+
+	"github.com/zegl/bazel_dependency_tools/internal"
+	"github.com/zegl/bazel_dependency_tools/internal/download"
+	"github.com/zegl/bazel_dependency_tools/internal/integrity"
+)
+
+// Verify downloads the current version from all urls, and checks that they
+// match the declared sha256 and integrity. It's used for http_archive, http_file and
+// http_jar, which share the same attributes.
+func Verify(e *syntax.CallExpr, rule, namePrefixFilter string, downloader *download.Downloader) (*internal.Verification, error) {
+	attrs := parseAttributes(e)
+
+	// Don't verify this dependency
+	if !strings.HasPrefix(attrs.name, namePrefixFilter) {
+		return nil, nil
+	}
+
+	if len(attrs.urls) == 0 {
+		return nil, fmt.Errorf("unable to parse %s", attrs.name)
+	}
+
+	log.Printf("Verifying %s", attrs.name)
+
+	start, _ := e.Span()
+	res := &internal.Verification{
+		Rule:     rule,
+		Name:     attrs.name,
+		Filename: start.Filename(),
+		Line:     start.Line,
+	}
+
+	if attrs.sha256 == nil && attrs.integrity == nil {
+		res.Err = internal.ErrNoChecksum
+		return res, nil
+	}
+
+	// Every url is downloaded, even if the archive is in the repository cache,
+	// to find mirrors and re-tagged releases that don't match the checksum
+	var mismatches []string
+	for _, url := range attrs.urls {
+		data, err := downloader.GetPage(url.Value.(string))
+		if err != nil {
+			mismatches = append(mismatches, err.Error())
+			continue
+		}
+		before := len(mismatches)
+
+		if attrs.sha256 != nil {
+			expected := attrs.sha256.Value.(string)
+			if sha256sum := fmt.Sprintf("%x", sha256.Sum256(data)); sha256sum != expected {
+				mismatches = append(mismatches, fmt.Sprintf("checksum mismatch for %s: expected %s, got %s", url.Value.(string), expected, sha256sum))
+			}
+		}
+
+		if attrs.integrity != nil {
+			if err := integrity.Verify(attrs.integrity.Value.(string), data); err != nil {
+				mismatches = append(mismatches, fmt.Sprintf("%s: %s", url.Value.(string), err))
+			}
+		}
+
+		// Only archives that match their checksum are added to the cache
+		if len(mismatches) == before {
+			downloader.AddToCache(data)
+		}
+	}
+
+	if len(mismatches) > 0 {
+		res.Err = errors.New(strings.Join(mismatches, "; "))
+	}
+
+	return res, nil
+}
@@ -0,0 +1,157 @@
+package archive
+
+import (
+	"archive/tar"
+	"archive/zip"
+	"bytes"
+	"compress/bzip2"
+	"compress/gzip"
+	"errors"
+	"fmt"
+	"io"
+	"io/ioutil"
+	"os/exec"
+	"path"
+	"strings"
+)
+
+// ErrUnsupportedFormat is returned for archives that can't be listed
+var ErrUnsupportedFormat = errors.New("unsupported archive format")
+
+// Entries returns the paths of all files and directories in the archive, where
+// directories end with a slash. The format is detected from the extension of
+// name, which is usually the URL that the archive was downloaded from.
+func Entries(name string, data []byte) ([]string, error) {
+	var res []string
+	err := walk(name, data, func(path string, r io.Reader) error {
+		res = append(res, path)
+		return nil
+	})
+	return res, err
+}
+
+// ReadFiles returns the content of all files in the archive for which match
+// returns true, by their path
+func ReadFiles(name string, data []byte, match func(path string) bool) (map[string][]byte, error) {
+	res := make(map[string][]byte)
+	err := walk(name, data, func(path string, r io.Reader) error {
+		if strings.HasSuffix(path, "/") || !match(path) {
+			return nil
+		}
+		content, err := ioutil.ReadAll(r)
+		if err != nil {
+			return fmt.Errorf("failed to read %s: %w", path, err)
+		}
+		res[path] = content
+		return nil
+	})
+	return res, err
+}
+
+// walk calls fn for all entries of the archive, with a reader for the content
+// of the entry
+func walk(name string, data []byte, fn func(path string, r io.Reader) error) error {
+	switch {
+	case strings.HasSuffix(name, ".tar.gz"), strings.HasSuffix(name, ".tgz"):
+		r, err := gzip.NewReader(bytes.NewReader(data))
+		if err != nil {
+			return fmt.Errorf("failed to read %s: %w", name, err)
+		}
+		return walkTar(r, fn)
+	case strings.HasSuffix(name, ".tar.bz2"):
+		return walkTar(bzip2.NewReader(bytes.NewReader(data)), fn)
+	case strings.HasSuffix(name, ".tar.xz"):
+		// There is no xz decoder in the standard library, use the xz binary
+		cmd := exec.Command("xz", "-dc")
+		cmd.Stdin = bytes.NewReader(data)
+		out, err := cmd.Output()
+		if err != nil {
+			return fmt.Errorf("failed to decompress %s: %w", name, err)
+		}
+		return walkTar(bytes.NewReader(out), fn)
+	case strings.HasSuffix(name, ".tar"):
+		return walkTar(bytes.NewReader(data), fn)
+	case strings.HasSuffix(name, ".zip"), strings.HasSuffix(name, ".jar"):
+		return walkZip(data, fn)
+	}
+
+	return ErrUnsupportedFormat
+}
+
+func walkTar(r io.Reader, fn func(path string, r io.Reader) error) error {
+	tr := tar.NewReader(r)
+	for {
+		hdr, err := tr.Next()
+		if err == io.EOF {
+			return nil
+		}
+		if err != nil {
+			return fmt.Errorf("failed to read tar: %w", err)
+		}
+
+		// PAX headers are metadata, not entries
+		if hdr.Typeflag == tar.TypeXGlobalHeader || hdr.Typeflag == tar.TypeXHeader {
+			continue
+		}
+
+		name := hdr.Name
+		if hdr.Typeflag == tar.TypeDir && !strings.HasSuffix(name, "/") {
+			name += "/"
+		}
+		if err := fn(name, tr); err != nil {
+			return err
+		}
+	}
+}
+
+func walkZip(data []byte, fn func(path string, r io.Reader) error) error {
+	zr, err := zip.NewReader(bytes.NewReader(data), int64(len(data)))
+	if err != nil {
+		return fmt.Errorf("failed to read zip: %w", err)
+	}
+
+	for _, f := range zr.File {
+		rc, err := f.Open()
+		if err != nil {
+			return fmt.Errorf("failed to read %s: %w", f.Name, err)
+		}
+		err = fn(f.Name, rc)
+		rc.Close()
+		if err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// TopLevelDir returns the single directory that contains all entries of the
+// archive, which is the value to use as strip_prefix. An empty string is
+// returned if there are files in the root of the archive, or more than one
+// directory.
+func TopLevelDir(name string, data []byte) (string, error) {
+	entries, err := Entries(name, data)
+	if err != nil {
+		return "", err
+	}
+
+	var dir string
+	for _, entry := range entries {
+		isDir := strings.HasSuffix(entry, "/")
+		entry = strings.TrimPrefix(path.Clean("/"+entry), "/")
+		if entry == "" {
+			continue
+		}
+
+		parts := strings.SplitN(entry, "/", 2)
+		if len(parts) == 1 && !isDir {
+			// A file in the root of the archive
+			return "", nil
+		}
+		if dir != "" && parts[0] != dir {
+			return "", nil
+		}
+		dir = parts[0]
+	}
+
+	return dir, nil
+}
@@ -0,0 +1,105 @@
+package archive
+
+import (
+	"archive/tar"
+	"archive/zip"
+	"bytes"
+	"compress/gzip"
+	"os/exec"
+	"strings"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func tarGz(t *testing.T, names ...string) []byte {
+	var buf bytes.Buffer
+	gz := gzip.NewWriter(&buf)
+	tw := tar.NewWriter(gz)
+	for _, name := range names {
+		hdr := &tar.Header{Name: name, Mode: 0644, Typeflag: tar.TypeReg}
+		if name[len(name)-1] == '/' {
+			hdr.Typeflag = tar.TypeDir
+			hdr.Mode = 0755
+		}
+		assert.Nil(t, tw.WriteHeader(hdr))
+	}
+	assert.Nil(t, tw.Close())
+	assert.Nil(t, gz.Close())
+	return buf.Bytes()
+}
+
+func zipFile(t *testing.T, names ...string) []byte {
+	var buf bytes.Buffer
+	zw := zip.NewWriter(&buf)
+	for _, name := range names {
+		_, err := zw.Create(name)
+		assert.Nil(t, err)
+	}
+	assert.Nil(t, zw.Close())
+	return buf.Bytes()
+}
+
+func TestTopLevelDir(t *testing.T) {
+	cases := []struct {
+		name     string
+		data     []byte
+		expected string
+	}{
+		{"a.tar.gz", tarGz(t, "rules_foo-1.2.3/", "rules_foo-1.2.3/BUILD", "rules_foo-1.2.3/src/foo.go"), "rules_foo-1.2.3"},
+		{"a.tgz", tarGz(t, "./foo/BUILD", "./foo/WORKSPACE"), "foo"},
+		{"a.tar.gz", tarGz(t, "foo/BUILD", "bar/BUILD"), ""},
+		{"a.tar.gz", tarGz(t, "foo/BUILD", "README"), ""},
+		{"a.zip", zipFile(t, "foo-main/", "foo-main/BUILD"), "foo-main"},
+		{"a.zip", zipFile(t, "BUILD", "WORKSPACE"), ""},
+	}
+
+	for _, tc := range cases {
+		dir, err := TopLevelDir(tc.name, tc.data)
+		assert.Nil(t, err)
+		assert.Equal(t, tc.expected, dir)
+	}
+}
+
+func TestTopLevelDirXz(t *testing.T) {
+	if _, err := exec.LookPath("xz"); err != nil {
+		t.Skip("xz is not installed")
+	}
+
+	var tarData bytes.Buffer
+	tw := tar.NewWriter(&tarData)
+	assert.Nil(t, tw.WriteHeader(&tar.Header{Name: "foo-1.0/BUILD", Mode: 0644, Typeflag: tar.TypeReg}))
+	assert.Nil(t, tw.Close())
+
+	cmd := exec.Command("xz", "-zc")
+	cmd.Stdin = &tarData
+	data, err := cmd.Output()
+	assert.Nil(t, err)
+
+	dir, err := TopLevelDir("foo-1.0.tar.xz", data)
+	assert.Nil(t, err)
+	assert.Equal(t, "foo-1.0", dir)
+}
+
+func TestUnsupportedFormat(t *testing.T) {
+	_, err := TopLevelDir("foo.rar", nil)
+	assert.Equal(t, ErrUnsupportedFormat, err)
+}
+
+func TestReadFiles(t *testing.T) {
+	var buf bytes.Buffer
+	zw := zip.NewWriter(&buf)
+	for name, content := range map[string]string{"foo/LICENSE": "license", "foo/src/main.go": "package main"} {
+		w, err := zw.Create(name)
+		assert.Nil(t, err)
+		_, err = w.Write([]byte(content))
+		assert.Nil(t, err)
+	}
+	assert.Nil(t, zw.Close())
+
+	files, err := ReadFiles("foo.zip", buf.Bytes(), func(path string) bool {
+		return strings.HasSuffix(path, "LICENSE")
+	})
+	assert.Nil(t, err)
+	assert.Equal(t, map[string][]byte{"foo/LICENSE": []byte("license")}, files)
+}
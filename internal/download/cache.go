@@ -0,0 +1,96 @@
+package download
+
+import (
+	"crypto/sha256"
+	"fmt"
+	"io/ioutil"
+	"os"
+	"os/user"
+	"path/filepath"
+	"runtime"
+)
+
+// DefaultRepositoryCache returns the default location of Bazel's repository
+// cache for the current user, or an empty string if it doesn't exist
+func DefaultRepositoryCache() string {
+	u, err := user.Current()
+	if err != nil {
+		return ""
+	}
+
+	var outputUserRoot string
+	switch runtime.GOOS {
+	case "darwin":
+		outputUserRoot = filepath.Join("/private/var/tmp", "_bazel_"+u.Username)
+	case "windows":
+		outputUserRoot = filepath.Join(os.Getenv("LOCALAPPDATA"), "_bazel_"+u.Username)
+	default:
+		outputUserRoot = filepath.Join(u.HomeDir, ".cache", "bazel", "_bazel_"+u.Username)
+	}
+
+	cache := filepath.Join(outputUserRoot, "cache", "repos", "v1")
+	if _, err := os.Stat(cache); err != nil {
+		return ""
+	}
+	return cache
+}
+
+// cachePath returns the path of the file with the sha256 in the repository
+// cache, this is the same layout as used by Bazel
+func (d *Downloader) cachePath(sha256sum string) string {
+	return filepath.Join(d.Cache, "content_addressable", "sha256", sha256sum, "file")
+}
+
+// fromCache returns the file with the sha256 from the repository cache, if
+// it exists and is not corrupt
+func (d *Downloader) fromCache(sha256sum string) ([]byte, bool) {
+	if d == nil || d.Cache == "" || sha256sum == "" {
+		return nil, false
+	}
+
+	data, err := ioutil.ReadFile(d.cachePath(sha256sum))
+	if err != nil {
+		return nil, false
+	}
+
+	if fmt.Sprintf("%x", sha256.Sum256(data)) != sha256sum {
+		return nil, false
+	}
+
+	return data, true
+}
+
+// toCache writes data to the repository cache. Errors are ignored, the cache
+// is only an optimization.
+func (d *Downloader) toCache(data []byte) {
+	if d == nil || d.Cache == "" {
+		return
+	}
+
+	path := d.cachePath(fmt.Sprintf("%x", sha256.Sum256(data)))
+	if _, err := os.Stat(path); err == nil {
+		return
+	}
+
+	if err := os.MkdirAll(filepath.Dir(path), 0755); err != nil {
+		return
+	}
+
+	// Write to a temporary file first, so that Bazel never reads a partial file
+	tmp, err := ioutil.TempFile(filepath.Dir(path), "tmp")
+	if err != nil {
+		return
+	}
+	_, err = tmp.Write(data)
+	if closeErr := tmp.Close(); err == nil {
+		err = closeErr
+	}
+	if err != nil {
+		os.Remove(tmp.Name())
+		return
+	}
+
+	if err := os.Rename(tmp.Name(), path); err != nil {
+		os.Remove(tmp.Name())
+	}
+}
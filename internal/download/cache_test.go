@@ -0,0 +1,60 @@
+package download
+
+import (
+	"crypto/sha256"
+	"fmt"
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestRepositoryCache(t *testing.T) {
+	cache, err := ioutil.TempDir("", "repository_cache")
+	assert.Nil(t, err)
+	defer os.RemoveAll(cache)
+
+	sha256sum := fmt.Sprintf("%x", sha256.Sum256([]byte("archive")))
+
+	d := NewFakeDownloader(map[string][]byte{
+		"https://example.com/archive.tar.gz": []byte("archive"),
+	})
+	d.Cache = cache
+
+	// Downloaded files are added to the cache
+	data, err := d.Get("https://example.com/archive.tar.gz")
+	assert.Nil(t, err)
+	assert.Equal(t, "archive", string(data))
+
+	cached, err := ioutil.ReadFile(filepath.Join(cache, "content_addressable", "sha256", sha256sum, "file"))
+	assert.Nil(t, err)
+	assert.Equal(t, "archive", string(cached))
+
+	// Pages are not added to the cache
+	d = NewFakeDownloader(map[string][]byte{
+		"https://example.com/": []byte("index"),
+	})
+	d.Cache = cache
+
+	data, err = d.GetPage("https://example.com/")
+	assert.Nil(t, err)
+	assert.Equal(t, "index", string(data))
+
+	_, err = os.Stat(filepath.Join(cache, "content_addressable", "sha256", fmt.Sprintf("%x", sha256.Sum256([]byte("index")))))
+	assert.True(t, os.IsNotExist(err))
+
+	// Files in the cache are not downloaded again
+	d = NewFakeDownloader(nil)
+	d.Cache = cache
+
+	data, err = d.GetSha256("https://example.com/archive.tar.gz", sha256sum)
+	assert.Nil(t, err)
+	assert.Equal(t, "archive", string(data))
+
+	// Corrupt files in the cache are ignored
+	assert.Nil(t, ioutil.WriteFile(filepath.Join(cache, "content_addressable", "sha256", sha256sum, "file"), []byte("corrupt"), 0644))
+	_, err = d.GetSha256("https://example.com/archive.tar.gz", sha256sum)
+	assert.NotNil(t, err)
+}
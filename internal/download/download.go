@@ -0,0 +1,124 @@
+package download
+
+import (
+	"bytes"
+	"crypto/sha1"
+	"crypto/sha256"
+	"fmt"
+	"io/ioutil"
+	"net/http"
+)
+
+// Downloader fetches archives over HTTP. The zero value, and a nil
+// *Downloader, use http.DefaultClient.
+type Downloader struct {
+	Client *http.Client
+
+	// Cache is the path to Bazel's repository cache. Downloaded files are
+	// added to the cache, so that Bazel doesn't have to download them again.
+	Cache string
+}
+
+func (d *Downloader) client() *http.Client {
+	if d == nil || d.Client == nil {
+		return http.DefaultClient
+	}
+	return d.Client
+}
+
+// Get downloads the file at url and returns its content. The file is added to
+// the repository cache.
+func (d *Downloader) Get(url string) ([]byte, error) {
+	data, err := d.GetPage(url)
+	if err != nil {
+		return nil, err
+	}
+
+	d.toCache(data)
+
+	return data, nil
+}
+
+// AddToCache adds a file that was downloaded with GetPage to the repository
+// cache, once its checksum has been verified
+func (d *Downloader) AddToCache(data []byte) {
+	d.toCache(data)
+}
+
+// GetPage downloads url and returns the response body, without adding it to
+// the repository cache. It's used for directory listings and other pages that
+// Bazel never downloads.
+func (d *Downloader) GetPage(url string) ([]byte, error) {
+	resp, err := d.client().Get(url)
+	if err != nil {
+		return nil, fmt.Errorf("failed to download %s: %w", url, err)
+	}
+	defer resp.Body.Close()
+
+	if resp.StatusCode != http.StatusOK {
+		return nil, fmt.Errorf("failed to download %s: unexpected status %s", url, resp.Status)
+	}
+
+	data, err := ioutil.ReadAll(resp.Body)
+	if err != nil {
+		return nil, fmt.Errorf("failed to download %s: %w", url, err)
+	}
+
+	return data, nil
+}
+
+// GetSha256 returns the file with the expected sha256 from the repository
+// cache, or downloads it from url if it's not in the cache. The checksum of
+// the downloaded file is not verified.
+func (d *Downloader) GetSha256(url, sha256sum string) ([]byte, error) {
+	if data, ok := d.fromCache(sha256sum); ok {
+		return data, nil
+	}
+	return d.Get(url)
+}
+
+// Sha256 downloads url and returns the hex encoded sha256 of its content
+func (d *Downloader) Sha256(url string) (string, error) {
+	data, err := d.Get(url)
+	if err != nil {
+		return "", err
+	}
+	return fmt.Sprintf("%x", sha256.Sum256(data)), nil
+}
+
+// Sha1 downloads url and returns the hex encoded sha1 of its content
+func (d *Downloader) Sha1(url string) (string, error) {
+	data, err := d.Get(url)
+	if err != nil {
+		return "", err
+	}
+	return fmt.Sprintf("%x", sha1.Sum(data)), nil
+}
+
+type fakeTransport map[string][]byte
+
+func (f fakeTransport) RoundTrip(req *http.Request) (*http.Response, error) {
+	data, ok := f[req.URL.String()]
+	if !ok {
+		return &http.Response{
+			StatusCode: http.StatusNotFound,
+			Status:     "404 Not Found",
+			Body:       ioutil.NopCloser(bytes.NewReader(nil)),
+			Request:    req,
+		}, nil
+	}
+
+	return &http.Response{
+		StatusCode:    http.StatusOK,
+		Status:        "200 OK",
+		Body:          ioutil.NopCloser(bytes.NewReader(data)),
+		ContentLength: int64(len(data)),
+		Request:       req,
+	}, nil
+}
+
+// NewFakeDownloader returns a Downloader that serves files from memory, keyed
+// by their URL, instead of making HTTP requests
+func NewFakeDownloader(files map[string][]byte) *Downloader {
+	return &Downloader{Client: &http.Client{Transport: fakeTransport(files)}}
+}
@@ -0,0 +1,90 @@
+package github
+
+import (
+	"bytes"
+	"io/ioutil"
+	"net/http"
+	"sync"
+)
+
+type cachedResponse struct {
+	etag   string
+	header http.Header
+	body   []byte
+}
+
+// etagTransport makes GET requests conditional on the ETag of a previous
+// response to the same URL. GitHub does not count requests answered with 304
+// Not Modified against the rate limit.
+type etagTransport struct {
+	base http.RoundTripper
+
+	mu    sync.Mutex
+	cache map[string]cachedResponse
+}
+
+// NewETagTransport wraps base with a transport that caches responses in memory
+// and revalidates them with conditional requests
+func NewETagTransport(base http.RoundTripper) http.RoundTripper {
+	if base == nil {
+		base = http.DefaultTransport
+	}
+	return &etagTransport{base: base, cache: make(map[string]cachedResponse)}
+}
+
+func (t *etagTransport) RoundTrip(req *http.Request) (*http.Response, error) {
+	if req.Method != http.MethodGet {
+		return t.base.RoundTrip(req)
+	}
+
+	key := req.URL.String()
+
+	t.mu.Lock()
+	cached, ok := t.cache[key]
+	t.mu.Unlock()
+
+	if ok {
+		req = req.Clone(req.Context())
+		req.Header.Set("If-None-Match", cached.etag)
+	}
+
+	resp, err := t.base.RoundTrip(req)
+	if err != nil {
+		return nil, err
+	}
+
+	if ok && resp.StatusCode == http.StatusNotModified {
+		resp.Body.Close()
+
+		// Serve the cached body, but keep the fresh rate limit headers
+		header := cached.header.Clone()
+		for k, v := range resp.Header {
+			header[k] = v
+		}
+
+		resp.StatusCode = http.StatusOK
+		resp.Status = "200 OK"
+		resp.Header = header
+		resp.Body = ioutil.NopCloser(bytes.NewReader(cached.body))
+		resp.ContentLength = int64(len(cached.body))
+		return resp, nil
+	}
+
+	etag := resp.Header.Get("ETag")
+	if resp.StatusCode != http.StatusOK || etag == "" {
+		return resp, nil
+	}
+
+	body, err := ioutil.ReadAll(resp.Body)
+	resp.Body.Close()
+	if err != nil {
+		return nil, err
+	}
+	resp.Body = ioutil.NopCloser(bytes.NewReader(body))
+
+	t.mu.Lock()
+	t.cache[key] = cachedResponse{etag: etag, header: resp.Header.Clone(), body: body}
+	t.mu.Unlock()
+
+	return resp, nil
+}
@@ -2,20 +2,48 @@ package github
 
 import (
 	"context"
+	"fmt"
+	"time"
+
 	"github.com/google/go-github/v28/github"
 )
 
 type Client interface {
 	ListReleases(owner, repo string) ([]*github.RepositoryRelease, error)
+
+	// LatestCommit returns the SHA of the newest commit on branch. An empty
+	// branch is the default branch of the repository.
+	LatestCommit(owner, repo, branch string) (string, error)
+
+	// License returns the SPDX identifier of the license that GitHub detected
+	// for the repository
+	License(owner, repo string) (string, error)
+}
+
+// Hosts maps the hostname of a GitHub instance, such as "github.com" or a
+// GitHub Enterprise server, to a client for its API
+type Hosts map[string]Client
+
+// Match returns the client for host, if host is a known GitHub instance
+func (h Hosts) Match(host string) (Client, bool) {
+	if host == "" {
+		return nil, false
+	}
+	c, ok := h[host]
+	return c, ok
 }
 
 type fakeClient struct {
 	releases map[string][]*github.RepositoryRelease
+	commits  map[string]string
+	licenses map[string]string
 }
 
 func NewFakeClient() *fakeClient {
 	return &fakeClient{
 		releases: make(map[string][]*github.RepositoryRelease),
+		commits:  make(map[string]string),
+		licenses: make(map[string]string),
 	}
 }
 
@@ -30,19 +58,145 @@ func (f *fakeClient) AddRelease(owner, repo, tag, tarURL string) {
 	})
 }
 
+func (f *fakeClient) AddReleaseWithNotes(owner, repo, tag, tarURL, body string) {
+	f.AddRelease(owner, repo, tag, tarURL)
+	releases := f.releases[owner+repo]
+	releases[len(releases)-1].Body = &body
+}
+
 func (f *fakeClient) ListReleases(owner, repo string) ([]*github.RepositoryRelease, error) {
 	return f.releases[owner+repo], nil
 }
 
+// AddCommit sets the newest commit of branch, use an empty branch for the default branch
+func (f *fakeClient) AddCommit(owner, repo, branch, sha string) {
+	f.commits[owner+repo+"@"+branch] = sha
+}
+
+func (f *fakeClient) LatestCommit(owner, repo, branch string) (string, error) {
+	if sha, ok := f.commits[owner+repo+"@"+branch]; ok {
+		return sha, nil
+	}
+	return "", fmt.Errorf("no commits on %s/%s@%s", owner, repo, branch)
+}
+
+func (f *fakeClient) AddLicense(owner, repo, spdxID string) {
+	f.licenses[owner+repo] = spdxID
+}
+
+func (f *fakeClient) License(owner, repo string) (string, error) {
+	if l, ok := f.licenses[owner+repo]; ok {
+		return l, nil
+	}
+	return "", fmt.Errorf("no license for %s/%s", owner, repo)
+}
+
 type githubClient struct {
-	c *github.Client
+	c     *github.Client
+	rate  github.Rate
+	sleep func(time.Duration)
 }
 
 func NewGithubClient(client *github.Client) *githubClient {
-	return &githubClient{c: client}
+	return &githubClient{c: client, sleep: time.Sleep}
 }
 
+// ListReleases returns all published releases of the repository. Repositories
+// that don't use GitHub releases, but only push tags, have their tags returned
+// as releases instead.
 func (g *githubClient) ListReleases(owner, repo string) ([]*github.RepositoryRelease, error) {
-	releases, _, err := g.c.Repositories.ListReleases(context.Background(), owner, repo, nil)
-	return releases, err
+	var releases []*github.RepositoryRelease
+	opts := &github.ListOptions{PerPage: 100}
+
+	for {
+		var page []*github.RepositoryRelease
+		var resp *github.Response
+		err := g.do(func() (r *github.Response, err error) {
+			page, resp, err = g.c.Repositories.ListReleases(context.Background(), owner, repo, opts)
+			return resp, err
+		})
+		if err != nil {
+			return nil, err
+		}
+
+		for _, release := range page {
+			if release.GetDraft() {
+				continue
+			}
+			releases = append(releases, release)
+		}
+
+		if resp.NextPage == 0 {
+			break
+		}
+		opts.Page = resp.NextPage
+	}
+
+	if len(releases) > 0 {
+		return releases, nil
+	}
+
+	return g.listTags(owner, repo)
+}
+
+func (g *githubClient) LatestCommit(owner, repo, branch string) (string, error) {
+	ref := branch
+	if ref == "" {
+		ref = "HEAD"
+	}
+
+	var sha string
+	err := g.do(func() (resp *github.Response, err error) {
+		sha, resp, err = g.c.Repositories.GetCommitSHA1(context.Background(), owner, repo, ref, "")
+		return resp, err
+	})
+	return sha, err
+}
+
+func (g *githubClient) License(owner, repo string) (string, error) {
+	var license *github.RepositoryLicense
+	err := g.do(func() (resp *github.Response, err error) {
+		license, resp, err = g.c.Repositories.License(context.Background(), owner, repo)
+		return resp, err
+	})
+	if err != nil {
+		return "", err
+	}
+
+	// GitHub uses NOASSERTION for license files that it could not classify
+	id := license.GetLicense().GetSPDXID()
+	if id == "" || id == "NOASSERTION" {
+		return "", fmt.Errorf("unknown license for %s/%s", owner, repo)
+	}
+	return id, nil
+}
+
+func (g *githubClient) listTags(owner, repo string) ([]*github.RepositoryRelease, error) {
+	var releases []*github.RepositoryRelease
+	opts := &github.ListOptions{PerPage: 100}
+
+	for {
+		var page []*github.RepositoryTag
+		var resp *github.Response
+		err := g.do(func() (r *github.Response, err error) {
+			page, resp, err = g.c.Repositories.ListTags(context.Background(), owner, repo, opts)
+			return resp, err
+		})
+		if err != nil {
+			return nil, err
+		}
+
+		for _, tag := range page {
+			releases = append(releases, &github.RepositoryRelease{
+				TagName: tag.Name,
+			})
+		}
+
+		if resp.NextPage == 0 {
+			break
+		}
+		opts.Page = resp.NextPage
+	}
+
+	return releases, nil
 }
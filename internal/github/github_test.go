@@ -0,0 +1,169 @@
+package github
+
+import (
+	"fmt"
+	"io/ioutil"
+	"net/http"
+	"net/http/httptest"
+	"net/url"
+	"testing"
+	"time"
+
+	"github.com/google/go-github/v28/github"
+	"github.com/stretchr/testify/assert"
+)
+
+func newTestClient(server *httptest.Server) *githubClient {
+	c := github.NewClient(nil)
+	c.BaseURL, _ = url.Parse(server.URL + "/")
+	return NewGithubClient(c)
+}
+
+func TestListReleasesPaginated(t *testing.T) {
+	mux := http.NewServeMux()
+	mux.HandleFunc("/repos/bazelbuild/rules_go/releases", func(w http.ResponseWriter, r *http.Request) {
+		switch r.URL.Query().Get("page") {
+		case "", "1":
+			w.Header().Set("Link", fmt.Sprintf(`<http://%s/repos/bazelbuild/rules_go/releases?page=2>; rel="next"`, r.Host))
+			fmt.Fprint(w, `[{"tag_name": "0.20.0"}, {"tag_name": "0.21.0-rc1", "draft": true}]`)
+		case "2":
+			fmt.Fprint(w, `[{"tag_name": "0.19.0"}]`)
+		}
+	})
+
+	server := httptest.NewServer(mux)
+	defer server.Close()
+
+	releases, err := newTestClient(server).ListReleases("bazelbuild", "rules_go")
+	assert.Nil(t, err)
+
+	var tags []string
+	for _, r := range releases {
+		tags = append(tags, r.GetTagName())
+	}
+	assert.Equal(t, []string{"0.20.0", "0.19.0"}, tags)
+}
+
+func TestListReleasesFallbackToTags(t *testing.T) {
+	mux := http.NewServeMux()
+	mux.HandleFunc("/repos/bazelbuild/bazel-skylib/releases", func(w http.ResponseWriter, r *http.Request) {
+		fmt.Fprint(w, `[]`)
+	})
+	mux.HandleFunc("/repos/bazelbuild/bazel-skylib/tags", func(w http.ResponseWriter, r *http.Request) {
+		fmt.Fprint(w, `[{"name": "1.0.2"}, {"name": "1.0.1"}]`)
+	})
+
+	server := httptest.NewServer(mux)
+	defer server.Close()
+
+	releases, err := newTestClient(server).ListReleases("bazelbuild", "bazel-skylib")
+	assert.Nil(t, err)
+	assert.Len(t, releases, 2)
+	assert.Equal(t, "1.0.2", releases[0].GetTagName())
+	assert.Equal(t, "1.0.1", releases[1].GetTagName())
+}
+
+func TestLicense(t *testing.T) {
+	mux := http.NewServeMux()
+	mux.HandleFunc("/repos/bazelbuild/rules_go/license", func(w http.ResponseWriter, r *http.Request) {
+		fmt.Fprint(w, `{"name": "LICENSE.txt", "license": {"key": "apache-2.0", "spdx_id": "Apache-2.0"}}`)
+	})
+	mux.HandleFunc("/repos/example/custom/license", func(w http.ResponseWriter, r *http.Request) {
+		fmt.Fprint(w, `{"name": "LICENSE", "license": {"key": "other", "spdx_id": "NOASSERTION"}}`)
+	})
+
+	server := httptest.NewServer(mux)
+	defer server.Close()
+
+	license, err := newTestClient(server).License("bazelbuild", "rules_go")
+	assert.Nil(t, err)
+	assert.Equal(t, "Apache-2.0", license)
+
+	_, err = newTestClient(server).License("example", "custom")
+	assert.NotNil(t, err)
+}
+
+func TestListReleasesRetry(t *testing.T) {
+	calls := 0
+	mux := http.NewServeMux()
+	mux.HandleFunc("/repos/bazelbuild/rules_go/releases", func(w http.ResponseWriter, r *http.Request) {
+		calls++
+		switch calls {
+		case 1:
+			w.WriteHeader(http.StatusBadGateway)
+		case 2:
+			w.Header().Set("Retry-After", "30")
+			w.WriteHeader(http.StatusForbidden)
+			fmt.Fprint(w, `{"message": "You have exceeded a secondary rate limit."}`)
+		default:
+			w.Header().Set("X-RateLimit-Limit", "5000")
+			w.Header().Set("X-RateLimit-Remaining", "4998")
+			w.Header().Set("X-RateLimit-Reset", "1577836800")
+			fmt.Fprint(w, `[{"tag_name": "0.20.0"}]`)
+		}
+	})
+
+	server := httptest.NewServer(mux)
+	defer server.Close()
+
+	client := newTestClient(server)
+	var slept []time.Duration
+	client.sleep = func(d time.Duration) {
+		slept = append(slept, d)
+	}
+
+	releases, err := client.ListReleases("bazelbuild", "rules_go")
+	assert.Nil(t, err)
+	assert.Len(t, releases, 1)
+	assert.Equal(t, 3, calls)
+	assert.Equal(t, []time.Duration{time.Second, 30 * time.Second}, slept)
+	assert.Equal(t, 4998, client.Rate().Remaining)
+	assert.Equal(t, 5000, client.Rate().Limit)
+}
+
+func TestListReleasesNoRetryOnNotFound(t *testing.T) {
+	calls := 0
+	mux := http.NewServeMux()
+	mux.HandleFunc("/repos/bazelbuild/rules_go/releases", func(w http.ResponseWriter, r *http.Request) {
+		calls++
+		w.WriteHeader(http.StatusNotFound)
+	})
+
+	server := httptest.NewServer(mux)
+	defer server.Close()
+
+	client := newTestClient(server)
+	client.sleep = func(d time.Duration) {}
+
+	_, err := client.ListReleases("bazelbuild", "rules_go")
+	assert.Error(t, err)
+	assert.Equal(t, 1, calls)
+}
+
+func TestETagTransport(t *testing.T) {
+	calls, notModified := 0, 0
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		calls++
+		if r.Header.Get("If-None-Match") == `"abc"` {
+			notModified++
+			w.WriteHeader(http.StatusNotModified)
+			return
+		}
+		w.Header().Set("ETag", `"abc"`)
+		fmt.Fprint(w, `[{"tag_name": "0.20.0"}]`)
+	}))
+	defer server.Close()
+
+	client := &http.Client{Transport: NewETagTransport(nil)}
+	for i := 0; i < 2; i++ {
+		resp, err := client.Get(server.URL)
+		assert.Nil(t, err)
+		body, _ := ioutil.ReadAll(resp.Body)
+		resp.Body.Close()
+		assert.Equal(t, http.StatusOK, resp.StatusCode)
+		assert.Equal(t, `[{"tag_name": "0.20.0"}]`, string(body))
+	}
+
+	assert.Equal(t, 2, calls)
+	assert.Equal(t, 1, notModified)
+}
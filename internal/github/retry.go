@@ -0,0 +1,92 @@
+package github
+
+import (
+	"log"
+	"net/http"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/google/go-github/v28/github"
+)
+
+const (
+	// maxRetries is the number of times a failed request is retried
+	maxRetries = 5
+
+	// maxRateLimitWait is the longest time to wait for the primary rate limit
+	// to reset. If the reset is further away, the request fails instead.
+	maxRateLimitWait = 15 * time.Minute
+)
+
+// do runs fn, retrying it when GitHub reports that the client is rate limited
+// or when the request fails with a server error. The rate limit budget reported
+// by GitHub is recorded on the client.
+func (g *githubClient) do(fn func() (*github.Response, error)) error {
+	for attempt := 0; ; attempt++ {
+		resp, err := fn()
+		if resp != nil && resp.Rate.Limit > 0 {
+			g.rate = resp.Rate
+		}
+		if err == nil {
+			return nil
+		}
+
+		wait, retry := retryAfter(resp, err, attempt)
+		if !retry || attempt >= maxRetries {
+			return err
+		}
+
+		log.Printf("GitHub request failed, retrying in %s: %s", wait, err)
+		g.sleep(wait)
+	}
+}
+
+// retryAfter returns how long to wait before retrying a request that failed
+// with err, and if the request should be retried at all.
+func retryAfter(resp *github.Response, err error, attempt int) (time.Duration, bool) {
+	backoff := time.Second << uint(attempt)
+
+	switch e := err.(type) {
+	case *github.AbuseRateLimitError:
+		if e.RetryAfter != nil {
+			return *e.RetryAfter, true
+		}
+		return backoff, true
+	case *github.RateLimitError:
+		wait := time.Until(e.Rate.Reset.Time)
+		if wait > maxRateLimitWait {
+			return 0, false
+		}
+		if wait < 0 {
+			wait = 0
+		}
+		return wait + time.Second, true
+	}
+
+	if resp == nil || resp.Response == nil {
+		return 0, false
+	}
+
+	if resp.StatusCode >= 500 {
+		return backoff, true
+	}
+
+	// Secondary rate limits are reported as a 403 with a Retry-After header,
+	// or with a message that the client does not recognize as an abuse limit.
+	if resp.StatusCode == http.StatusForbidden {
+		if seconds, err := strconv.Atoi(resp.Header.Get("Retry-After")); err == nil {
+			return time.Duration(seconds) * time.Second, true
+		}
+		if strings.Contains(strings.ToLower(err.Error()), "secondary rate limit") {
+			return backoff, true
+		}
+	}
+
+	return 0, false
+}
+
+// Rate returns the most recent rate limit budget reported by GitHub
+func (g *githubClient) Rate() github.Rate {
+	return g.rate
+}
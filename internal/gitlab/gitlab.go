@@ -0,0 +1,133 @@
+package gitlab
+
+import (
+	"encoding/json"
+	"fmt"
+	"io/ioutil"
+	"net/http"
+	"net/url"
+)
+
+// Release is a GitLab release, or a tag for projects that don't use releases
+type Release struct {
+	TagName     string `json:"tag_name"`
+	Description string `json:"description"`
+	Links       struct {
+		Self string `json:"self"`
+	} `json:"_links"`
+	Assets struct {
+		Links []struct {
+			Name string `json:"name"`
+			URL  string `json:"url"`
+		} `json:"links"`
+	} `json:"assets"`
+}
+
+type Client interface {
+	// ListReleases returns the releases of a project, where project is the
+	// full path of the project, eg. "gitlab-org/gitlab-runner"
+	ListReleases(project string) ([]Release, error)
+}
+
+type fakeClient struct {
+	releases map[string][]Release
+}
+
+func NewFakeClient() *fakeClient {
+	return &fakeClient{
+		releases: make(map[string][]Release),
+	}
+}
+
+func (f *fakeClient) AddRelease(project, tag, description string) {
+	f.releases[project] = append(f.releases[project], Release{
+		TagName:     tag,
+		Description: description,
+	})
+}
+
+func (f *fakeClient) ListReleases(project string) ([]Release, error) {
+	return f.releases[project], nil
+}
+
+type gitlabClient struct {
+	c       *http.Client
+	baseURL string
+	token   string
+}
+
+// NewGitlabClient creates a client for the GitLab API at baseURL, eg.
+// "https://gitlab.com/api/v4". The token is optional.
+func NewGitlabClient(client *http.Client, baseURL, token string) *gitlabClient {
+	return &gitlabClient{c: client, baseURL: baseURL, token: token}
+}
+
+// ListReleases returns all releases of the project. Projects that don't use
+// GitLab releases, but only push tags, have their tags returned as releases
+// instead.
+func (g *gitlabClient) ListReleases(project string) ([]Release, error) {
+	var releases []Release
+	if err := g.list(fmt.Sprintf("/projects/%s/releases", url.PathEscape(project)), func(data []byte) error {
+		var page []Release
+		err := json.Unmarshal(data, &page)
+		releases = append(releases, page...)
+		return err
+	}); err != nil {
+		return nil, err
+	}
+
+	if len(releases) > 0 {
+		return releases, nil
+	}
+
+	err := g.list(fmt.Sprintf("/projects/%s/repository/tags", url.PathEscape(project)), func(data []byte) error {
+		var page []struct {
+			Name string `json:"name"`
+		}
+		err := json.Unmarshal(data, &page)
+		for _, tag := range page {
+			releases = append(releases, Release{TagName: tag.Name})
+		}
+		return err
+	})
+
+	return releases, err
+}
+
+// list requests all pages of a paginated API endpoint
+func (g *gitlabClient) list(path string, parse func(data []byte) error) error {
+	page := "1"
+
+	for page != "" {
+		req, err := http.NewRequest(http.MethodGet, fmt.Sprintf("%s%s?per_page=100&page=%s", g.baseURL, path, page), nil)
+		if err != nil {
+			return err
+		}
+		if g.token != "" {
+			req.Header.Set("PRIVATE-TOKEN", g.token)
+		}
+
+		resp, err := g.c.Do(req)
+		if err != nil {
+			return err
+		}
+
+		data, err := ioutil.ReadAll(resp.Body)
+		resp.Body.Close()
+		if err != nil {
+			return err
+		}
+
+		if resp.StatusCode != http.StatusOK {
+			return fmt.Errorf("GitLab request to %s failed: %s", path, resp.Status)
+		}
+
+		if err := parse(data); err != nil {
+			return fmt.Errorf("unmarshal GitLab response failed: %w", err)
+		}
+
+		page = resp.Header.Get("X-Next-Page")
+	}
+
+	return nil
+}
@@ -0,0 +1,50 @@
+package gitlab
+
+import (
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestListReleasesPaginated(t *testing.T) {
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		assert.Equal(t, "/projects/gitlab-org%2Fgitlab-runner/releases", r.URL.EscapedPath())
+		assert.Equal(t, "secret", r.Header.Get("PRIVATE-TOKEN"))
+		switch r.URL.Query().Get("page") {
+		case "1":
+			w.Header().Set("X-Next-Page", "2")
+			fmt.Fprint(w, `[{"tag_name": "v12.6.0", "description": "Bug fixes"}]`)
+		case "2":
+			fmt.Fprint(w, `[{"tag_name": "v12.5.0"}]`)
+		}
+	}))
+	defer server.Close()
+
+	releases, err := NewGitlabClient(http.DefaultClient, server.URL, "secret").ListReleases("gitlab-org/gitlab-runner")
+	assert.Nil(t, err)
+	assert.Len(t, releases, 2)
+	assert.Equal(t, "v12.6.0", releases[0].TagName)
+	assert.Equal(t, "Bug fixes", releases[0].Description)
+	assert.Equal(t, "v12.5.0", releases[1].TagName)
+}
+
+func TestListReleasesFallbackToTags(t *testing.T) {
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		switch r.URL.EscapedPath() {
+		case "/projects/group%2Fproject/releases":
+			fmt.Fprint(w, `[]`)
+		case "/projects/group%2Fproject/repository/tags":
+			fmt.Fprint(w, `[{"name": "1.0.1"}, {"name": "1.0.0"}]`)
+		default:
+			w.WriteHeader(http.StatusNotFound)
+		}
+	}))
+	defer server.Close()
+
+	releases, err := NewGitlabClient(http.DefaultClient, server.URL, "").ListReleases("group/project")
+	assert.Nil(t, err)
+	assert.Equal(t, []Release{{TagName: "1.0.1"}, {TagName: "1.0.0"}}, releases)
+}
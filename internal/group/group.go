@@ -0,0 +1,231 @@
+package group
+
+import (
+	"fmt"
+	"strings"
+
+	"github.com/blang/semver"
+
+	"github.com/zegl/bazel_dependency_tools/internal"
+	isemver "github.com/zegl/bazel_dependency_tools/internal/semver"
+)
+
+// Group is a set of dependencies that are always upgraded together. A
+// dependency is a member if it matches any of the prefixes, Maven groupIds or
+// GitHub owners.
+type Group struct {
+	Name          string   `json:"name"`
+	Prefixes      []string `json:"prefixes"`
+	MavenGroupIDs []string `json:"maven_group_ids"`
+	GitHubOwners  []string `json:"github_owners"`
+
+	// Align upgrades all members to the same version, the highest version
+	// that is available for all of them. See AlignedVersions.
+	Align bool `json:"align"`
+}
+
+// Result is a set of upgrades that should be applied together. Group is empty
+// for dependencies that are not a member of any group. If Err is set, none of
+// the upgrades in the result should be applied.
+type Result struct {
+	Group    string
+	Upgrades []internal.Upgrade
+	Err      error
+}
+
+func (g Group) matches(u internal.Upgrade) bool {
+	for _, prefix := range g.Prefixes {
+		if strings.HasPrefix(u.Name, prefix) {
+			return true
+		}
+	}
+
+	switch u.Rule {
+	case "maven_jar", "maven_install":
+		groupID := strings.Split(u.Coordinate, ":")[0]
+		for _, id := range g.MavenGroupIDs {
+			if id == groupID {
+				return true
+			}
+		}
+	case "http_archive":
+		if u.Source != internal.SourceGitHub {
+			return false
+		}
+		// Coordinate is "host/owner/repo"
+		parts := strings.Split(u.Coordinate, "/")
+		if len(parts) >= 2 {
+			for _, owner := range g.GitHubOwners {
+				if owner == parts[1] {
+					return true
+				}
+			}
+		}
+	}
+
+	return false
+}
+
+// Resolve partitions the upgrades into groups. Each upgrade is assigned to the
+// first group that it matches. Upgrades that don't match any group are returned
+// as a Result of their own. The order of the results follows the order of the
+// first member of each group.
+func Resolve(upgrades []internal.Upgrade, groups []Group) []Result {
+	var results []Result
+	groupResult := make(map[string]int)
+
+	for _, u := range upgrades {
+		var member *Group
+		for i := range groups {
+			if groups[i].matches(u) {
+				member = &groups[i]
+				break
+			}
+		}
+
+		if member == nil {
+			results = append(results, Result{Upgrades: []internal.Upgrade{u}, Err: u.Err})
+			continue
+		}
+
+		idx, ok := groupResult[member.Name]
+		if !ok {
+			idx = len(results)
+			groupResult[member.Name] = idx
+			results = append(results, Result{Group: member.Name})
+		}
+		results[idx].Upgrades = append(results[idx].Upgrades, u)
+	}
+
+	for _, g := range groups {
+		if idx, ok := groupResult[g.Name]; ok {
+			results[idx].Err = check(g, results[idx].Upgrades)
+		}
+	}
+
+	return results
+}
+
+// AlignedVersions returns the versions that the members of aligned groups must
+// be upgraded to instead of their newest version, by coordinate. The aligned
+// version of a group is the lowest of the newest versions of its members, as
+// that is the highest version that is available for all of them, eg. if
+// io.netty:netty-handler 4.1.2 is not released yet, netty-codec is upgraded to
+// 4.1.1 as well. Groups are not aligned if any member is unresolved, if a
+// version can't be parsed, or if a member would be downgraded.
+func AlignedVersions(upgrades []internal.Upgrade, groups []Group) map[string]string {
+	res := make(map[string]string)
+
+	for _, r := range Resolve(upgrades, groups) {
+		g := find(groups, r.Group)
+		if g == nil || !g.Align || r.Err == nil {
+			continue
+		}
+
+		if hasUnresolved(r.Upgrades) {
+			continue
+		}
+		aligned, ok := lowestNewVersion(r.Upgrades)
+		if !ok {
+			continue
+		}
+		for _, u := range r.Upgrades {
+			if u.NewVersion != aligned {
+				res[u.Coordinate] = aligned
+			}
+		}
+	}
+
+	return res
+}
+
+func hasUnresolved(upgrades []internal.Upgrade) bool {
+	for _, u := range upgrades {
+		if u.Err != nil {
+			return true
+		}
+	}
+	return false
+}
+
+func find(groups []Group, name string) *Group {
+	for i := range groups {
+		if groups[i].Name == name {
+			return &groups[i]
+		}
+	}
+	return nil
+}
+
+// lowestNewVersion returns the lowest new version of the upgrades, and false
+// if it's older than the current version of any of them
+func lowestNewVersion(upgrades []internal.Upgrade) (string, bool) {
+	var lowest string
+	var lowestVersion *semver.Version
+	for _, u := range upgrades {
+		v, err := isemver.ParseTag(u.NewVersion).Semver()
+		if err != nil {
+			return "", false
+		}
+		if lowestVersion == nil || v.LT(*lowestVersion) {
+			lowest, lowestVersion = u.NewVersion, v
+		}
+	}
+
+	for _, u := range upgrades {
+		old, err := isemver.ParseTag(u.OldVersion).Semver()
+		if err != nil || lowestVersion.LT(*old) {
+			return "", false
+		}
+	}
+
+	return lowest, lowestVersion != nil
+}
+
+// check returns an error if the group can not be upgraded as a whole
+func check(g Group, upgrades []internal.Upgrade) error {
+	for _, u := range upgrades {
+		if u.Err != nil {
+			return fmt.Errorf("%s could not be resolved: %w", describe(u), u.Err)
+		}
+	}
+
+	if !g.Align {
+		return nil
+	}
+
+	var version string
+	for _, u := range upgrades {
+		if version == "" {
+			version = u.NewVersion
+		}
+		if u.NewVersion != version {
+			return fmt.Errorf("versions are not aligned: %s is at %s, expected %s", describe(u), u.NewVersion, version)
+		}
+	}
+
+	return nil
+}
+
+func describe(u internal.Upgrade) string {
+	if u.Coordinate != "" {
+		return u.Coordinate
+	}
+	return u.Name
+}
+
+// Replacements returns the replacements of all results that can be applied.
+// If onlyGroup is set, only the replacements of that group are returned.
+func Replacements(results []Result, onlyGroup string) []internal.LineReplacement {
+	var res []internal.LineReplacement
+	for _, r := range results {
+		if r.Err != nil {
+			continue
+		}
+		if onlyGroup != "" && r.Group != onlyGroup {
+			continue
+		}
+		res = append(res, internal.Replacements(r.Upgrades)...)
+	}
+	return res
+}
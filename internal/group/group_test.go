@@ -0,0 +1,117 @@
+package group
+
+import (
+	"errors"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+
+	"github.com/zegl/bazel_dependency_tools/internal"
+)
+
+func TestResolve(t *testing.T) {
+	upgrades := []internal.Upgrade{
+		{Rule: "http_archive", Name: "io_bazel_rules_go", Coordinate: "github.com/bazelbuild/rules_go", Source: internal.SourceGitHub, OldVersion: "0.19.3", NewVersion: "0.19.4"},
+		{Rule: "maven_install", Name: "maven", Coordinate: "io.netty:netty-codec", OldVersion: "4.1.0", NewVersion: "4.1.1"},
+		{Rule: "maven_install", Name: "maven", Coordinate: "com.google.guava:guava", OldVersion: "27.0", NewVersion: "28.0"},
+		{Rule: "http_archive", Name: "bazel_gazelle", Coordinate: "github.com/bazelbuild/bazel-gazelle", Source: internal.SourceGitHub, OldVersion: "0.18.0", NewVersion: "0.19.0"},
+		{Rule: "maven_install", Name: "maven", Coordinate: "io.netty:netty-handler", OldVersion: "4.1.0", NewVersion: "4.1.1"},
+	}
+
+	results := Resolve(upgrades, []Group{
+		{Name: "bazel", GitHubOwners: []string{"bazelbuild"}},
+		{Name: "netty", MavenGroupIDs: []string{"io.netty"}, Align: true},
+	})
+
+	assert.Len(t, results, 3)
+	assert.Equal(t, "bazel", results[0].Group)
+	assert.Equal(t, []internal.Upgrade{upgrades[0], upgrades[3]}, results[0].Upgrades)
+	assert.Nil(t, results[0].Err)
+	assert.Equal(t, "netty", results[1].Group)
+	assert.Equal(t, []internal.Upgrade{upgrades[1], upgrades[4]}, results[1].Upgrades)
+	assert.Nil(t, results[1].Err)
+	assert.Equal(t, "", results[2].Group)
+	assert.Equal(t, []internal.Upgrade{upgrades[2]}, results[2].Upgrades)
+}
+
+func TestResolveSkipsUnresolved(t *testing.T) {
+	upgrades := []internal.Upgrade{
+		{Rule: "http_archive", Name: "io_bazel_rules_go", OldVersion: "0.19.3", NewVersion: "0.19.4", Replacements: []internal.LineReplacement{{Line: 1}}},
+		{Rule: "http_archive", Name: "io_bazel_rules_sass", Err: errors.New("rate limited")},
+		{Rule: "http_archive", Name: "bazel_gazelle", OldVersion: "0.18.0", NewVersion: "0.19.0", Replacements: []internal.LineReplacement{{Line: 2}}},
+	}
+
+	results := Resolve(upgrades, []Group{
+		{Name: "rules", Prefixes: []string{"io_bazel_rules_"}},
+	})
+
+	assert.Len(t, results, 2)
+	assert.Error(t, results[0].Err)
+	assert.Equal(t, []internal.LineReplacement{{Line: 2}}, Replacements(results, ""))
+	assert.Nil(t, Replacements(results, "rules"))
+}
+
+func TestResolveNotAligned(t *testing.T) {
+	upgrades := []internal.Upgrade{
+		{Rule: "maven_jar", Name: "netty_codec", Coordinate: "io.netty:netty-codec", OldVersion: "4.1.0", NewVersion: "4.1.2"},
+		{Rule: "maven_jar", Name: "netty_handler", Coordinate: "io.netty:netty-handler", OldVersion: "4.1.0", NewVersion: "4.1.1"},
+	}
+
+	results := Resolve(upgrades, []Group{
+		{Name: "netty", MavenGroupIDs: []string{"io.netty"}, Align: true},
+	})
+
+	assert.Len(t, results, 1)
+	assert.EqualError(t, results[0].Err, "versions are not aligned: io.netty:netty-handler is at 4.1.1, expected 4.1.2")
+}
+
+func TestResolveGitHubOwnersOnlyMatchGitHub(t *testing.T) {
+	upgrades := []internal.Upgrade{
+		{Rule: "http_archive", Name: "rules_go", Coordinate: "github.com/bazelbuild/rules_go", Source: internal.SourceGitHub},
+		{Rule: "http_archive", Name: "tool", Coordinate: "gitlab.com/bazelbuild/tool", Source: internal.SourceGitLab},
+		{Rule: "http_archive", Name: "other", Coordinate: "dl.example.org/bazelbuild/other-{version}.tar.gz", Source: internal.SourceIndex},
+	}
+
+	results := Resolve(upgrades, []Group{
+		{Name: "bazel", GitHubOwners: []string{"bazelbuild"}},
+	})
+
+	assert.Len(t, results, 3)
+	assert.Equal(t, "bazel", results[0].Group)
+	assert.Equal(t, []internal.Upgrade{upgrades[0]}, results[0].Upgrades)
+	assert.Equal(t, "", results[1].Group)
+	assert.Equal(t, "", results[2].Group)
+}
+
+func TestAlignedVersions(t *testing.T) {
+	groups := []Group{
+		{Name: "netty", MavenGroupIDs: []string{"io.netty"}, Align: true},
+		{Name: "grpc", MavenGroupIDs: []string{"io.grpc"}},
+	}
+
+	// netty-handler 4.1.2 is not released yet
+	assert.Equal(t, map[string]string{"io.netty:netty-codec": "4.1.1"}, AlignedVersions([]internal.Upgrade{
+		{Rule: "maven_jar", Coordinate: "io.netty:netty-codec", OldVersion: "4.1.0", NewVersion: "4.1.2"},
+		{Rule: "maven_jar", Coordinate: "io.netty:netty-handler", OldVersion: "4.1.0", NewVersion: "4.1.1"},
+		{Rule: "maven_jar", Coordinate: "io.grpc:grpc-core", OldVersion: "1.0.0", NewVersion: "1.2.0"},
+		{Rule: "maven_jar", Coordinate: "io.grpc:grpc-api", OldVersion: "1.0.0", NewVersion: "1.1.0"},
+	}, groups))
+
+	// Already aligned
+	assert.Empty(t, AlignedVersions([]internal.Upgrade{
+		{Rule: "maven_jar", Coordinate: "io.netty:netty-codec", OldVersion: "4.1.0", NewVersion: "4.1.2"},
+		{Rule: "maven_jar", Coordinate: "io.netty:netty-handler", OldVersion: "4.0.0", NewVersion: "4.1.2"},
+	}, groups))
+
+	// netty-codec would be downgraded
+	assert.Empty(t, AlignedVersions([]internal.Upgrade{
+		{Rule: "maven_jar", Coordinate: "io.netty:netty-codec", OldVersion: "4.1.2", NewVersion: "4.1.3"},
+		{Rule: "maven_jar", Coordinate: "io.netty:netty-handler", OldVersion: "4.1.0", NewVersion: "4.1.1"},
+	}, groups))
+
+	// Unresolved members
+	assert.Empty(t, AlignedVersions([]internal.Upgrade{
+		{Rule: "maven_jar", Coordinate: "io.netty:netty-codec", OldVersion: "4.1.0", NewVersion: "4.1.2"},
+		{Rule: "maven_jar", Coordinate: "io.netty:netty-handler", OldVersion: "4.1.0", NewVersion: "4.1.0", Err: errors.New("not found")},
+	}, groups))
+}
@@ -0,0 +1,173 @@
+package index
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"net/url"
+	"path"
+	"regexp"
+	"strings"
+
+	"github.com/zegl/bazel_dependency_tools/internal/download"
+	isemver "github.com/zegl/bazel_dependency_tools/internal/semver"
+)
+
+// Placeholder marks the position of the version in a URL template
+const Placeholder = "{version}"
+
+// Host configures version discovery for archives downloaded from a plain web
+// server, that is neither GitHub nor a Maven repository.
+type Host struct {
+	// Host is the hostname of the server, eg. "dl.example.org"
+	Host string `json:"host"`
+
+	// Index is the format of the directory listings on the server, "html"
+	// (default) or "json". JSON listings are either a list of names, or a list
+	// of objects with a "name", as served by nginx' autoindex.
+	Index string `json:"index"`
+
+	// ListURL is an optional endpoint that returns the available versions,
+	// either as a JSON list or one version per line. If empty, the versions
+	// are found by listing the directory that contains the archive.
+	ListURL string `json:"list_url"`
+}
+
+type Hosts []Host
+
+// Match returns the configuration for the host of rawURL
+func (h Hosts) Match(rawURL string) (Host, bool) {
+	u, err := url.Parse(rawURL)
+	if err != nil {
+		return Host{}, false
+	}
+	for _, host := range h {
+		if host.Host == u.Host {
+			return host, true
+		}
+	}
+	return Host{}, false
+}
+
+var versionRegex = regexp.MustCompile(`\d+(\.\d+)+`)
+
+// Template infers the version of the archive at rawURL from its filename, and
+// returns the URL with all occurrences of the version replaced by Placeholder.
+func Template(rawURL string) (template, version string, err error) {
+	version = versionRegex.FindString(path.Base(rawURL))
+	if version == "" {
+		return "", "", fmt.Errorf("no version found in %s", rawURL)
+	}
+	return strings.Replace(rawURL, version, Placeholder, -1), version, nil
+}
+
+// Versions returns all versions available for the URL template
+func (h Host) Versions(downloader *download.Downloader, template string) ([]string, error) {
+	if h.ListURL != "" {
+		data, err := downloader.GetPage(h.ListURL)
+		if err != nil {
+			return nil, err
+		}
+		return matchVersions(parseList(data), Placeholder), nil
+	}
+
+	// The version is either a part of the name of the archive, or of the
+	// name of a directory, eg. https://dl.example.org/tool/1.2.3/tool-1.2.3.tar.gz.
+	// In the latter case the versions are listed from the parent directory.
+	dir, name := path.Split(template)
+	for strings.Contains(strings.TrimSuffix(dir, "/"), Placeholder) {
+		dir, name = path.Split(strings.TrimSuffix(dir, "/"))
+	}
+
+	if !strings.Contains(name, Placeholder) {
+		return nil, errors.New("unable to find the version in the URL")
+	}
+
+	data, err := downloader.GetPage(dir)
+	if err != nil {
+		return nil, err
+	}
+
+	var names []string
+	if h.Index == "json" {
+		names, err = parseJSONIndex(data)
+		if err != nil {
+			return nil, err
+		}
+	} else {
+		names = parseHTMLIndex(data)
+	}
+
+	return matchVersions(names, name), nil
+}
+
+// matchVersions returns the versions of the names that match the name template
+func matchVersions(names []string, nameTemplate string) []string {
+	parts := strings.SplitN(nameTemplate, Placeholder, 2)
+	re := regexp.MustCompile("^" + regexp.QuoteMeta(parts[0]) + `(v?\d+(?:\.\d+)*)` + regexp.QuoteMeta(parts[1]) + "/?$")
+
+	var versions []string
+	for _, name := range names {
+		if m := re.FindStringSubmatch(name); m != nil {
+			if _, err := isemver.NormalizeNew(m[1]); err == nil {
+				versions = append(versions, m[1])
+			}
+		}
+	}
+	return versions
+}
+
+var hrefRegex = regexp.MustCompile(`(?i)href\s*=\s*["']([^"']+)["']`)
+
+// parseHTMLIndex returns the last path segment of all links in an HTML
+// directory listing
+func parseHTMLIndex(data []byte) []string {
+	var names []string
+	for _, m := range hrefRegex.FindAllStringSubmatch(string(data), -1) {
+		href := m[1]
+		if unescaped, err := url.PathUnescape(href); err == nil {
+			href = unescaped
+		}
+
+		isDir := strings.HasSuffix(href, "/")
+		name := path.Base(strings.TrimSuffix(href, "/"))
+		if isDir {
+			name += "/"
+		}
+		names = append(names, name)
+	}
+	return names
+}
+
+func parseJSONIndex(data []byte) ([]string, error) {
+	var names []string
+	if err := json.Unmarshal(data, &names); err == nil {
+		return names, nil
+	}
+
+	var entries []struct {
+		Name string `json:"name"`
+	}
+	if err := json.Unmarshal(data, &entries); err != nil {
+		return nil, fmt.Errorf("unmarshal JSON index failed: %w", err)
+	}
+	for _, e := range entries {
+		names = append(names, e.Name)
+	}
+	return names, nil
+}
+
+// parseList parses a JSON list of versions, or a list with one version per line
+func parseList(data []byte) []string {
+	var versions []string
+	if err := json.Unmarshal(data, &versions); err == nil {
+		return versions
+	}
+
+	for _, line := range strings.Split(string(data), "\n") {
+		if line = strings.TrimSpace(line); line != "" {
+			versions = append(versions, line)
+		}
+	}
+	return versions
+}
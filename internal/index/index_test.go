@@ -0,0 +1,62 @@
+package index
+
+import (
+	"sort"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+
+	"github.com/zegl/bazel_dependency_tools/internal/download"
+)
+
+func TestTemplate(t *testing.T) {
+	template, version, err := Template("https://dl.example.org/tool/1.2.3/tool-1.2.3-linux-x86_64.tar.gz")
+	assert.Nil(t, err)
+	assert.Equal(t, "1.2.3", version)
+	assert.Equal(t, "https://dl.example.org/tool/{version}/tool-{version}-linux-x86_64.tar.gz", template)
+
+	_, _, err = Template("https://dl.example.org/tool/latest.tar.gz")
+	assert.Error(t, err)
+}
+
+func TestVersions(t *testing.T) {
+	downloader := download.NewFakeDownloader(map[string][]byte{
+		"https://dl.example.org/html/": []byte(`<html><body>
+<a href="../">../</a>
+<a href="tool-1.2.3.tar.gz">tool-1.2.3.tar.gz</a>
+<a href="tool-1.3.0.tar.gz">tool-1.3.0.tar.gz</a>
+<a href="/html/tool-1.3.0.tar.gz.sha256">tool-1.3.0.tar.gz.sha256</a>
+<a href="tool-latest.tar.gz">tool-latest.tar.gz</a>
+<a href='other-2.0.0.tar.gz'>other-2.0.0.tar.gz</a>
+</body></html>`),
+		"https://dl.example.org/json/":    []byte(`[{"name": "1.2.3", "type": "directory"}, {"name": "1.4.0", "type": "directory"}, {"name": "README", "type": "file"}]`),
+		"https://dl.example.org/versions": []byte("1.2.3\n1.5.0\n\n"),
+	})
+
+	tests := []struct {
+		host     Host
+		template string
+		expected []string
+	}{
+		{Host{}, "https://dl.example.org/html/tool-{version}.tar.gz", []string{"1.2.3", "1.3.0"}},
+		{Host{Index: "json"}, "https://dl.example.org/json/{version}/tool-{version}.tar.gz", []string{"1.2.3", "1.4.0"}},
+		{Host{ListURL: "https://dl.example.org/versions"}, "https://dl.example.org/tool-{version}.tar.gz", []string{"1.2.3", "1.5.0"}},
+	}
+
+	for _, tc := range tests {
+		versions, err := tc.host.Versions(downloader, tc.template)
+		assert.Nil(t, err)
+		sort.Strings(versions)
+		assert.Equal(t, tc.expected, versions, tc.template)
+	}
+}
+
+func TestHostsMatch(t *testing.T) {
+	hosts := Hosts{{Host: "dl.example.org"}}
+
+	_, ok := hosts.Match("https://dl.example.org/tool/tool-1.2.3.tar.gz")
+	assert.True(t, ok)
+
+	_, ok = hosts.Match("https://github.com/bazelbuild/rules_go/archive/0.19.3.zip")
+	assert.False(t, ok)
+}
@@ -0,0 +1,86 @@
+package integrity
+
+import (
+	"crypto/sha256"
+	"crypto/sha512"
+	"encoding/base64"
+	"encoding/hex"
+	"errors"
+	"fmt"
+	"hash"
+	"strings"
+)
+
+var algorithms = map[string]func() hash.Hash{
+	"sha256": sha256.New,
+	"sha384": sha512.New384,
+	"sha512": sha512.New,
+}
+
+// Parse splits a Subresource Integrity string, eg. "sha256-<base64>", into
+// the algorithm and the digest
+func Parse(sri string) (algorithm string, digest []byte, err error) {
+	parts := strings.SplitN(strings.TrimSpace(sri), "-", 2)
+	if len(parts) != 2 {
+		return "", nil, fmt.Errorf("invalid integrity %q", sri)
+	}
+
+	newHash, ok := algorithms[parts[0]]
+	if !ok {
+		return "", nil, fmt.Errorf("unsupported integrity algorithm %q", parts[0])
+	}
+
+	digest, err = base64.StdEncoding.DecodeString(parts[1])
+	if err != nil {
+		return "", nil, fmt.Errorf("invalid integrity %q: %w", sri, err)
+	}
+	if len(digest) != newHash().Size() {
+		return "", nil, fmt.Errorf("invalid integrity %q: unexpected digest length", sri)
+	}
+
+	return parts[0], digest, nil
+}
+
+// Compute returns the integrity of data using algorithm
+func Compute(algorithm string, data []byte) (string, error) {
+	newHash, ok := algorithms[algorithm]
+	if !ok {
+		return "", fmt.Errorf("unsupported integrity algorithm %q", algorithm)
+	}
+
+	h := newHash()
+	h.Write(data)
+	return format(algorithm, h.Sum(nil)), nil
+}
+
+// FromHexSha256 converts a hex encoded sha256, as used by the sha256
+// attribute, to an integrity string
+func FromHexSha256(s string) (string, error) {
+	digest, err := hex.DecodeString(s)
+	if err != nil || len(digest) != sha256.Size {
+		return "", errors.New("invalid sha256: " + s)
+	}
+	return format("sha256", digest), nil
+}
+
+// Verify returns an error if data doesn't match the integrity sri
+func Verify(sri string, data []byte) error {
+	algorithm, _, err := Parse(sri)
+	if err != nil {
+		return err
+	}
+
+	actual, err := Compute(algorithm, data)
+	if err != nil {
+		return err
+	}
+
+	if actual != strings.TrimSpace(sri) {
+		return fmt.Errorf("integrity mismatch: expected %s, got %s", sri, actual)
+	}
+	return nil
+}
+
+func format(algorithm string, digest []byte) string {
+	return algorithm + "-" + base64.StdEncoding.EncodeToString(digest)
+}
@@ -0,0 +1,39 @@
+package integrity
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestCompute(t *testing.T) {
+	sri, err := Compute("sha256", []byte("rules_pkg"))
+	assert.Nil(t, err)
+	assert.Equal(t, "sha256-xQD+N3UJSK+uIshDAehp/RXkK16BBKu996W7sKFW+sI=", sri)
+
+	sri, err = Compute("sha384", []byte("rules_pkg"))
+	assert.Nil(t, err)
+	algorithm, digest, err := Parse(sri)
+	assert.Nil(t, err)
+	assert.Equal(t, "sha384", algorithm)
+	assert.Len(t, digest, 48)
+
+	_, err = Compute("md5", []byte("rules_pkg"))
+	assert.NotNil(t, err)
+}
+
+func TestFromHexSha256(t *testing.T) {
+	sri, err := FromHexSha256("c500fe37750948afae22c84301e869fd15e42b5e8104abbdf7a5bbb0a156fac2")
+	assert.Nil(t, err)
+	assert.Equal(t, "sha256-xQD+N3UJSK+uIshDAehp/RXkK16BBKu996W7sKFW+sI=", sri)
+
+	_, err = FromHexSha256("c500fe")
+	assert.NotNil(t, err)
+}
+
+func TestVerify(t *testing.T) {
+	assert.Nil(t, Verify("sha256-xQD+N3UJSK+uIshDAehp/RXkK16BBKu996W7sKFW+sI=", []byte("rules_pkg")))
+	assert.NotNil(t, Verify("sha256-xQD+N3UJSK+uIshDAehp/RXkK16BBKu996W7sKFW+sI=", []byte("tampered")))
+	assert.NotNil(t, Verify("sha1-xQD+N3UJSK+uIshDAehp/RXkK16BBKu996W7sKFW+sI=", []byte("rules_pkg")))
+	assert.NotNil(t, Verify("sha256-xQD+", []byte("rules_pkg")))
+}
@@ -0,0 +1,153 @@
+// Package notices writes a THIRD_PARTY_NOTICES file, with the license texts
+// and NOTICE files of all dependencies
+package notices
+
+import (
+	"bufio"
+	"crypto/sha256"
+	"fmt"
+	"io"
+	"path"
+	"sort"
+	"strings"
+)
+
+// UnknownLicense is the group of dependencies without a license
+const UnknownLicense = "Unknown license"
+
+// Dependency is a dependency, and its license and NOTICE files
+type Dependency struct {
+	Name    string
+	License string   // The license, dependencies are grouped by it
+	URLs    []string // URLs of the license texts, listed if there are no license files
+
+	// Files are the license and NOTICE files by their name, eg. "LICENSE"
+	Files map[string][]byte
+}
+
+// text is a license or NOTICE file that is shared by one or more dependencies
+type text struct {
+	name    string // The file name of the first dependency that has it
+	content string
+	users   []string
+}
+
+type group struct {
+	license      string
+	dependencies []string
+	licenses     []*text
+	notices      []*text
+	missing      []Dependency // Dependencies without license files
+}
+
+// Write writes the attribution document. Dependencies are grouped by their
+// license, and identical texts are only written once per license.
+func Write(w io.Writer, deps []Dependency) error {
+	deps = append([]Dependency(nil), deps...)
+	sort.SliceStable(deps, func(i, j int) bool { return deps[i].Name < deps[j].Name })
+
+	groups := make(map[string]*group)
+	var order []string
+	texts := make(map[[sha256.Size]byte]*text)
+
+	for _, d := range deps {
+		license := d.License
+		if license == "" {
+			license = UnknownLicense
+		}
+
+		g, ok := groups[license]
+		if !ok {
+			g = &group{license: license}
+			groups[license] = g
+			order = append(order, license)
+		}
+		g.dependencies = append(g.dependencies, d.Name)
+
+		var names []string
+		for name := range d.Files {
+			names = append(names, name)
+		}
+		sort.Strings(names)
+
+		hasLicense := false
+		for _, name := range names {
+			content := strings.TrimSpace(strings.Replace(string(d.Files[name]), "\r\n", "\n", -1))
+			if content == "" {
+				continue
+			}
+			notice := IsNotice(name)
+			hasLicense = hasLicense || !notice
+
+			// Texts that only differ in whitespace are the same
+			key := sha256.Sum256([]byte(license + "\x00" + strings.Join(strings.Fields(content), " ")))
+			t, ok := texts[key]
+			if !ok {
+				t = &text{name: name, content: content}
+				texts[key] = t
+				if notice {
+					g.notices = append(g.notices, t)
+				} else {
+					g.licenses = append(g.licenses, t)
+				}
+			}
+			if len(t.users) == 0 || t.users[len(t.users)-1] != d.Name {
+				t.users = append(t.users, d.Name)
+			}
+		}
+
+		if !hasLicense {
+			g.missing = append(g.missing, d)
+		}
+	}
+
+	// Sort the groups by license, with the unknown licenses last
+	sort.Slice(order, func(i, j int) bool {
+		if (order[i] == UnknownLicense) != (order[j] == UnknownLicense) {
+			return order[j] == UnknownLicense
+		}
+		return order[i] < order[j]
+	})
+
+	bw := bufio.NewWriter(w)
+	fmt.Fprintln(bw, "THIRD PARTY NOTICES")
+	fmt.Fprintln(bw)
+	fmt.Fprintln(bw, "This file contains the licenses and notices of the third party dependencies,")
+	fmt.Fprintln(bw, "grouped by license.")
+
+	for _, license := range order {
+		g := groups[license]
+
+		fmt.Fprintln(bw)
+		fmt.Fprintln(bw, strings.Repeat("=", 80))
+		fmt.Fprintln(bw, g.license)
+		fmt.Fprintln(bw, strings.Repeat("=", 80))
+		fmt.Fprintln(bw)
+		fmt.Fprintln(bw, "Used by:")
+		for _, name := range g.dependencies {
+			fmt.Fprintf(bw, "  %s\n", name)
+		}
+
+		for _, t := range append(g.licenses, g.notices...) {
+			fmt.Fprintln(bw)
+			fmt.Fprintf(bw, "--- %s (%s) ---\n", t.name, strings.Join(t.users, ", "))
+			fmt.Fprintln(bw)
+			fmt.Fprintln(bw, t.content)
+		}
+
+		for _, d := range g.missing {
+			fmt.Fprintln(bw)
+			fmt.Fprintf(bw, "--- No license text found (%s) ---\n", d.Name)
+			for _, url := range d.URLs {
+				fmt.Fprintf(bw, "  %s\n", url)
+			}
+		}
+	}
+
+	return bw.Flush()
+}
+
+// IsNotice returns true if the file is a NOTICE file, and not a license text
+func IsNotice(name string) bool {
+	return strings.HasPrefix(strings.ToLower(path.Base(name)), "notice")
+}
@@ -0,0 +1,74 @@
+package notices
+
+import (
+	"bytes"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+const apache = `Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.`
+
+func TestWrite(t *testing.T) {
+	var out bytes.Buffer
+	assert.Nil(t, Write(&out, []Dependency{
+		{Name: "tool", License: "Apache-2.0", Files: map[string][]byte{
+			"LICENSE": []byte(apache + "\n"),
+			"NOTICE":  []byte("Tool\nCopyright 2019 Example\n"),
+		}},
+		{Name: "missing"},
+		{Name: "com.google.guava:guava:28.1-jre", License: "Apache-2.0", Files: map[string][]byte{
+			// The same text, wrapped differently
+			"META-INF/LICENSE.txt": []byte("Licensed under the Apache License, Version 2.0 (the \"License\"); you may not\r\nuse this file except in compliance with the License.\r\n"),
+		}},
+		{Name: "junit_junit", License: "EPL-1.0", URLs: []string{"http://www.eclipse.org/legal/epl-v10.html"}},
+		{Name: "empty", License: "Apache-2.0", Files: map[string][]byte{"NOTICE": []byte("\n")}},
+	}))
+
+	assert.Equal(t, `THIRD PARTY NOTICES
+
+This file contains the licenses and notices of the third party dependencies,
+grouped by license.
+
+================================================================================
+Apache-2.0
+================================================================================
+
+Used by:
+  com.google.guava:guava:28.1-jre
+  empty
+  tool
+
+--- META-INF/LICENSE.txt (com.google.guava:guava:28.1-jre, tool) ---
+
+Licensed under the Apache License, Version 2.0 (the "License"); you may not
+use this file except in compliance with the License.
+
+--- NOTICE (tool) ---
+
+Tool
+Copyright 2019 Example
+
+--- No license text found (empty) ---
+
+================================================================================
+EPL-1.0
+================================================================================
+
+Used by:
+  junit_junit
+
+--- No license text found (junit_junit) ---
+  http://www.eclipse.org/legal/epl-v10.html
+
+================================================================================
+Unknown license
+================================================================================
+
+Used by:
+  missing
+
+--- No license text found (missing) ---
+`, out.String())
+}
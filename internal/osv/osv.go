@@ -0,0 +1,390 @@
+// Package osv matches dependencies against a local copy of the OSV
+// vulnerability database, see https://ossf.github.io/osv-schema/
+package osv
+
+import (
+	"encoding/json"
+	"fmt"
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"sort"
+	"strings"
+
+	"github.com/zegl/bazel_dependency_tools/internal/archive"
+)
+
+// Ecosystems of the packages that can be queried
+const (
+	Maven = "Maven"
+	Go    = "Go"
+)
+
+// Vulnerability is an OSV advisory
+type Vulnerability struct {
+	ID        string     `json:"id"`
+	Summary   string     `json:"summary"`
+	Aliases   []string   `json:"aliases"`
+	Withdrawn string     `json:"withdrawn"`
+	Severity  []Severity `json:"severity"`
+	Affected  []Affected `json:"affected"`
+
+	DatabaseSpecific struct {
+		Severity string `json:"severity"`
+	} `json:"database_specific"`
+}
+
+// Severity is a score of a vulnerability, eg. a CVSS vector
+type Severity struct {
+	Type  string `json:"type"`
+	Score string `json:"score"`
+}
+
+// Affected are the affected versions of a package
+type Affected struct {
+	Package struct {
+		Ecosystem string `json:"ecosystem"`
+		Name      string `json:"name"`
+	} `json:"package"`
+	Ranges   []Range  `json:"ranges"`
+	Versions []string `json:"versions"`
+}
+
+// Range is a list of events that introduce and fix the vulnerability, in
+// the order of the versions
+type Range struct {
+	Type   string  `json:"type"` // "SEMVER", "ECOSYSTEM" or "GIT"
+	Repo   string  `json:"repo"` // The repository of GIT ranges
+	Events []Event `json:"events"`
+}
+
+// Event is a single event in a Range, only one of the fields is set
+type Event struct {
+	Introduced   string `json:"introduced,omitempty"`
+	Fixed        string `json:"fixed,omitempty"`
+	LastAffected string `json:"last_affected,omitempty"`
+	Limit        string `json:"limit,omitempty"`
+}
+
+// SeverityString returns the severity as reported by the database, eg. "HIGH",
+// or the first score if the database doesn't have one
+func (v *Vulnerability) SeverityString() string {
+	if v.DatabaseSpecific.Severity != "" {
+		return v.DatabaseSpecific.Severity
+	}
+	if len(v.Severity) > 0 {
+		return v.Severity[0].Type + " " + v.Severity[0].Score
+	}
+	return "UNKNOWN"
+}
+
+// Finding is a vulnerability that affects a version of a package
+type Finding struct {
+	Vulnerability *Vulnerability
+
+	// Affected describes the affected versions, eg. ">= 1.0, < 1.2.3"
+	Affected string
+
+	// Fixed is the lowest version that fixes the vulnerability, and is newer
+	// than the affected version. It's a commit for GitHub repositories, and
+	// empty if there is no fix in the same flavor as the affected version, eg.
+	// "-jre" for Guava.
+	Fixed string
+}
+
+// Database is a set of advisories, indexed by the packages they affect
+type Database struct {
+	packages map[string][]*Vulnerability // By ecosystem and name
+	repos    map[string][]*Vulnerability // By the repository of GIT ranges
+}
+
+// Load reads all advisories from a directory of JSON files, or from a zip
+// file like the exports at https://osv-vulnerabilities.storage.googleapis.com
+func Load(path string) (*Database, error) {
+	db := &Database{
+		packages: make(map[string][]*Vulnerability),
+		repos:    make(map[string][]*Vulnerability),
+	}
+
+	info, err := os.Stat(path)
+	if err != nil {
+		return nil, err
+	}
+
+	if !info.IsDir() {
+		data, err := ioutil.ReadFile(path)
+		if err != nil {
+			return nil, err
+		}
+		files, err := archive.ReadFiles(path, data, func(name string) bool {
+			return strings.HasSuffix(name, ".json")
+		})
+		if err != nil {
+			return nil, err
+		}
+		for name, content := range files {
+			if err := db.add(content); err != nil {
+				return nil, fmt.Errorf("%s: %w", name, err)
+			}
+		}
+		return db, nil
+	}
+
+	err = filepath.Walk(path, func(name string, info os.FileInfo, err error) error {
+		if err != nil || info.IsDir() || !strings.HasSuffix(name, ".json") {
+			return err
+		}
+		content, err := ioutil.ReadFile(name)
+		if err != nil {
+			return err
+		}
+		if err := db.add(content); err != nil {
+			return fmt.Errorf("%s: %w", name, err)
+		}
+		return nil
+	})
+	return db, err
+}
+
+func (db *Database) add(content []byte) error {
+	var v Vulnerability
+	if err := json.Unmarshal(content, &v); err != nil {
+		return err
+	}
+
+	if v.Withdrawn != "" {
+		return nil
+	}
+
+	for _, a := range v.Affected {
+		if a.Package.Name != "" {
+			key := a.Package.Ecosystem + "\x00" + a.Package.Name
+			db.packages[key] = appendOnce(db.packages[key], &v)
+		}
+		for _, r := range a.Ranges {
+			if r.Type == "GIT" {
+				key := repoKey(r.Repo)
+				db.repos[key] = appendOnce(db.repos[key], &v)
+			}
+		}
+	}
+
+	return nil
+}
+
+func appendOnce(vulns []*Vulnerability, v *Vulnerability) []*Vulnerability {
+	if len(vulns) > 0 && vulns[len(vulns)-1] == v {
+		return vulns
+	}
+	return append(vulns, v)
+}
+
+// repoKey normalizes the URL of a repository, eg. "https://github.com/Foo/bar.git"
+// is "github.com/foo/bar"
+func repoKey(url string) string {
+	url = strings.ToLower(strings.TrimSuffix(strings.TrimSuffix(url, "/"), ".git"))
+	if i := strings.Index(url, "://"); i >= 0 {
+		url = url[i+3:]
+	}
+	return url
+}
+
+// Query returns the vulnerabilities that affect a version of a package. The
+// name of Maven packages is "group:artifact", and the module path for Go.
+func (db *Database) Query(ecosystem, name, version string) []Finding {
+	compare, ok := comparators[ecosystem]
+	if !ok {
+		return nil
+	}
+
+	var res []Finding
+	for _, v := range db.packages[ecosystem+"\x00"+name] {
+		for _, a := range v.Affected {
+			if a.Package.Ecosystem != ecosystem || a.Package.Name != name {
+				continue
+			}
+			if f, ok := match(v, a, version, compare, flavors[ecosystem]); ok {
+				res = append(res, f)
+				break
+			}
+		}
+	}
+	return sortFindings(res)
+}
+
+// QueryGit returns the vulnerabilities that affect a tag of a repository, eg.
+// "github.com/owner/repo". Tags can only be matched against the explicit list
+// of affected versions, as the commits of GIT ranges are unknown.
+func (db *Database) QueryGit(repo, tag string) []Finding {
+	var res []Finding
+	for _, v := range db.repos[repoKey(repo)] {
+		for _, a := range v.Affected {
+			var fixed []string
+			matchesRepo := false
+			for _, r := range a.Ranges {
+				if r.Type != "GIT" || repoKey(r.Repo) != repoKey(repo) {
+					continue
+				}
+				matchesRepo = true
+				for _, e := range r.Events {
+					if e.Fixed != "" {
+						fixed = append(fixed, e.Fixed)
+					}
+				}
+			}
+			if !matchesRepo || !containsVersion(a.Versions, tag) {
+				continue
+			}
+
+			f := Finding{Vulnerability: v, Affected: "listed versions"}
+			if len(fixed) > 0 {
+				f.Fixed = fixed[0]
+			}
+			res = append(res, f)
+			break
+		}
+	}
+	return sortFindings(res)
+}
+
+// containsVersion returns true if the version is in the list, with or without
+// a "v" prefix
+func containsVersion(versions []string, version string) bool {
+	for _, v := range versions {
+		if v == version || strings.TrimPrefix(v, "v") == strings.TrimPrefix(version, "v") {
+			return true
+		}
+	}
+	return false
+}
+
+// match checks if the version is affected, by the explicit list of versions or
+// by the SEMVER and ECOSYSTEM ranges. If flavor is set, only fixes of the same
+// flavor as the version are used.
+func match(v *Vulnerability, a Affected, version string, compare func(a, b string) (int, bool), flavor func(string) string) (Finding, bool) {
+	f := Finding{Vulnerability: v}
+	affected := containsVersion(a.Versions, version)
+	if affected {
+		f.Affected = "listed versions"
+	}
+
+	// Versions that can't be parsed can only be in the list of versions
+	if _, ok := compare(version, version); !ok {
+		return f, affected
+	}
+
+	for _, r := range a.Ranges {
+		if r.Type != "SEMVER" && r.Type != "ECOSYSTEM" {
+			continue
+		}
+
+		events := sortEvents(r.Events, compare)
+		if inRange(events, version, compare) {
+			affected = true
+			f.Affected = describeRange(events)
+		}
+
+		// The lowest fix that is newer than the version
+		for _, e := range events {
+			if e.Fixed == "" {
+				continue
+			}
+			if flavor != nil && flavor(e.Fixed) != flavor(version) {
+				continue
+			}
+			if c, ok := compare(e.Fixed, version); ok && c > 0 {
+				if f.Fixed == "" {
+					f.Fixed = e.Fixed
+				} else if c, ok := compare(e.Fixed, f.Fixed); ok && c < 0 {
+					f.Fixed = e.Fixed
+				}
+			}
+		}
+	}
+
+	return f, affected
+}
+
+// sortEvents sorts the events by their version, where "introduced: 0" is the
+// lowest version
+func sortEvents(events []Event, compare func(a, b string) (int, bool)) []Event {
+	version := func(e Event) string {
+		return e.Introduced + e.Fixed + e.LastAffected + e.Limit
+	}
+	sorted := append([]Event(nil), events...)
+	sort.SliceStable(sorted, func(i, j int) bool {
+		vi, vj := version(sorted[i]), version(sorted[j])
+		if sorted[i].Introduced == "0" || sorted[j].Introduced == "0" {
+			return sorted[i].Introduced == "0" && sorted[j].Introduced != "0"
+		}
+		c, ok := compare(vi, vj)
+		return ok && c < 0
+	})
+	return sorted
+}
+
+// inRange evaluates the events of a range, which must be sorted
+func inRange(events []Event, version string, compare func(a, b string) (int, bool)) bool {
+	affected := false
+	for _, e := range events {
+		switch {
+		case e.Introduced == "0":
+			affected = true
+		case e.Introduced != "":
+			if c, ok := compare(e.Introduced, version); ok && c <= 0 {
+				affected = true
+			}
+		case e.Fixed != "":
+			if c, ok := compare(e.Fixed, version); ok && c <= 0 {
+				affected = false
+			}
+		case e.LastAffected != "":
+			if c, ok := compare(e.LastAffected, version); ok && c < 0 {
+				affected = false
+			}
+		case e.Limit != "":
+			if c, ok := compare(e.Limit, version); ok && c <= 0 {
+				affected = false
+			}
+		}
+	}
+	return affected
+}
+
+// describeRange formats the affected versions of a range, eg. ">= 1.0, < 1.2"
+func describeRange(events []Event) string {
+	var intervals []string
+	var current []string
+	for _, e := range events {
+		switch {
+		case e.Introduced != "":
+			current = nil
+			if e.Introduced != "0" {
+				current = append(current, ">= "+e.Introduced)
+			}
+		case e.Fixed != "":
+			intervals = append(intervals, strings.Join(append(current, "< "+e.Fixed), ", "))
+			current = nil
+		case e.LastAffected != "":
+			intervals = append(intervals, strings.Join(append(current, "<= "+e.LastAffected), ", "))
+			current = nil
+		}
+	}
+
+	// The last interval is open
+	if len(events) > 0 && events[len(events)-1].Introduced != "" {
+		if len(current) == 0 {
+			current = []string{"all versions"}
+		}
+		intervals = append(intervals, strings.Join(current, ", "))
+	}
+
+	return strings.Join(intervals, " or ")
+}
+
+func sortFindings(findings []Finding) []Finding {
+	sort.Slice(findings, func(i, j int) bool {
+		return findings[i].Vulnerability.ID < findings[j].Vulnerability.ID
+	})
+	return findings
+}
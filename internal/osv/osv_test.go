@@ -0,0 +1,130 @@
+package osv
+
+import (
+	"archive/zip"
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func ids(findings []Finding) []string {
+	var res []string
+	for _, f := range findings {
+		res = append(res, f.Vulnerability.ID)
+	}
+	return res
+}
+
+func TestQueryMaven(t *testing.T) {
+	db, err := Load("testdata")
+	assert.Nil(t, err)
+
+	findings := db.Query(Maven, "com.google.guava:guava", "28.1-jre")
+	assert.Equal(t, []string{"GHSA-5mg8-w23w-74h3", "GHSA-7g45-4rm6-3mm3"}, ids(findings))
+	assert.Equal(t, "< 30.0-android or < 30.0-jre", findings[0].Affected)
+	assert.Equal(t, "30.0-jre", findings[0].Fixed)
+	assert.Equal(t, "LOW", findings[0].Vulnerability.SeverityString())
+	assert.Equal(t, ">= 1.0, < 32.0.0-android", findings[1].Affected)
+	assert.Equal(t, "", findings[1].Fixed, "only fixed in the android flavor")
+
+	findings = db.Query(Maven, "com.google.guava:guava", "28.1-android")
+	assert.Equal(t, "30.0-android", findings[0].Fixed)
+	assert.Equal(t, "32.0.0-android", findings[1].Fixed)
+
+	assert.Equal(t, []string{"GHSA-7g45-4rm6-3mm3"}, ids(db.Query(Maven, "com.google.guava:guava", "31.1-jre")))
+	assert.Empty(t, db.Query(Maven, "com.google.guava:guava", "32.0.1-jre"))
+
+	// Withdrawn advisories are ignored
+	findings = db.Query(Maven, "junit:junit", "4.12")
+	assert.Equal(t, []string{"GHSA-269g-pwp5-87pp"}, ids(findings))
+	assert.Equal(t, "CVSS_V3 CVSS:3.1/AV:L/AC:L/PR:L/UI:N/S:U/C:L/I:N/A:N", findings[0].Vulnerability.SeverityString())
+	assert.Empty(t, db.Query(Maven, "junit:junit", "4.6"))
+}
+
+func TestQueryGo(t *testing.T) {
+	db, err := Load("testdata")
+	assert.Nil(t, err)
+
+	findings := db.Query(Go, "github.com/google/go-github/v28", "v28.1.1")
+	assert.Equal(t, []string{"GO-TEST-0001"}, ids(findings))
+	assert.Equal(t, "< 28.1.2 or >= 28.2.0, <= 28.2.1", findings[0].Affected)
+	assert.Equal(t, "28.1.2", findings[0].Fixed)
+
+	assert.Empty(t, db.Query(Go, "github.com/google/go-github/v28", "v28.1.2"))
+	assert.Equal(t, []string{"GO-TEST-0001"}, ids(db.Query(Go, "github.com/google/go-github/v28", "v28.2.1")))
+	assert.Empty(t, db.Query(Go, "github.com/google/go-github/v28", "v28.3.0"))
+	assert.Empty(t, db.Query(Go, "github.com/google/go-github/v28", "not-a-version"))
+}
+
+func TestQueryGit(t *testing.T) {
+	db, err := Load("testdata")
+	assert.Nil(t, err)
+
+	findings := db.QueryGit("github.com/bazelbuild/bazel-skylib", "1.0.0")
+	assert.Equal(t, []string{"OSV-TEST-0001"}, ids(findings))
+	assert.Equal(t, "8f2f6b8e6d3c1f3e1b7c2e3f2b1f0e9d8c7b6a59", findings[0].Fixed)
+
+	assert.Empty(t, db.QueryGit("github.com/bazelbuild/bazel-skylib", "1.0.2"))
+}
+
+func TestLoadZip(t *testing.T) {
+	dir, err := ioutil.TempDir("", "osv")
+	assert.Nil(t, err)
+	defer os.RemoveAll(dir)
+
+	f, err := os.Create(filepath.Join(dir, "all.zip"))
+	assert.Nil(t, err)
+	zw := zip.NewWriter(f)
+	for _, name := range []string{"GHSA-269g-pwp5-87pp.json", "GHSA-5mg8-w23w-74h3.json"} {
+		content, err := ioutil.ReadFile(filepath.Join("testdata", "maven", name))
+		assert.Nil(t, err)
+		w, err := zw.Create(name)
+		assert.Nil(t, err)
+		_, err = w.Write(content)
+		assert.Nil(t, err)
+	}
+	assert.Nil(t, zw.Close())
+	assert.Nil(t, f.Close())
+
+	db, err := Load(filepath.Join(dir, "all.zip"))
+	assert.Nil(t, err)
+	assert.Equal(t, []string{"GHSA-5mg8-w23w-74h3"}, ids(db.Query(Maven, "com.google.guava:guava", "28.1-jre")))
+}
+
+func TestCompareMaven(t *testing.T) {
+	cases := []struct {
+		a, b     string
+		expected int
+	}{
+		{"1.0", "1.0.0", 0},
+		{"1.0-final", "1", 0},
+		{"1.0-alpha-1", "1.0", -1},
+		{"1.0-alpha-1", "1.0-beta-1", -1},
+		{"1.0-RC1", "1.0-SNAPSHOT", -1},
+		{"1.0-SNAPSHOT", "1.0", -1},
+		{"1.0", "1.0-sp", -1},
+		{"1.0-sp", "1.0.1", -1},
+		{"28.1-jre", "30.0-jre", -1},
+		{"30.0-android", "30.0-jre", -1},
+		{"4.13.1", "4.12", 1},
+		{"1.10", "1.9", 1},
+	}
+
+	for _, tc := range cases {
+		c, ok := compareMaven(tc.a, tc.b)
+		assert.True(t, ok)
+		assert.Equal(t, tc.expected, c, "%s %s", tc.a, tc.b)
+	}
+}
+
+func TestMavenFlavor(t *testing.T) {
+	assert.Equal(t, "jre", mavenFlavor("28.1-jre"))
+	assert.Equal(t, "android", mavenFlavor("32.0.0-Android"))
+	assert.Equal(t, "", mavenFlavor("4.13.1"))
+	assert.Equal(t, "", mavenFlavor("1.0-SNAPSHOT"))
+	assert.Equal(t, "", mavenFlavor("1.0-RC1"))
+	assert.Equal(t, "", mavenFlavor("2.0-final"))
+}
@@ -0,0 +1,212 @@
+package osv
+
+import (
+	"strings"
+
+	"github.com/blang/semver"
+)
+
+// comparators compare two versions of an ecosystem, and return false if
+// either of them can't be parsed
+var comparators = map[string]func(a, b string) (int, bool){
+	Maven: compareMaven,
+	Go:    compareSemver,
+}
+
+// flavors return the flavor of a version, eg. "jre" for the Maven version
+// "28.1-jre". Fixes are only suggested in the same flavor as the version.
+var flavors = map[string]func(version string) string{
+	Maven: mavenFlavor,
+}
+
+// Compare compares two versions of a package in the ecosystem. It returns
+// false if the ecosystem is unknown, or if either version can't be parsed.
+func Compare(ecosystem, a, b string) (int, bool) {
+	compare, ok := comparators[ecosystem]
+	if !ok {
+		return 0, false
+	}
+	return compare(a, b)
+}
+
+// compareSemver compares Go module versions, with or without the "v" prefix
+func compareSemver(a, b string) (int, bool) {
+	va, err := semver.Parse(strings.TrimPrefix(a, "v"))
+	if err != nil {
+		return 0, false
+	}
+	vb, err := semver.Parse(strings.TrimPrefix(b, "v"))
+	if err != nil {
+		return 0, false
+	}
+	return va.Compare(vb), true
+}
+
+// The order of the well-known Maven qualifiers, a release has the empty
+// qualifier. Unknown qualifiers are newer than all of these, and are
+// compared alphabetically.
+var mavenQualifiers = map[string]int{
+	"alpha":     1,
+	"beta":      2,
+	"milestone": 3,
+	"rc":        4,
+	"snapshot":  5,
+	"":          6,
+	"sp":        7,
+}
+
+var mavenQualifierAliases = map[string]string{
+	"a":       "alpha",
+	"b":       "beta",
+	"m":       "milestone",
+	"cr":      "rc",
+	"ga":      "",
+	"final":   "",
+	"release": "",
+}
+
+// mavenItem is a part of a Maven version, either a number or a qualifier
+type mavenItem struct {
+	number    string // Without leading zeros, empty for qualifiers
+	qualifier string
+}
+
+// parseMaven splits a version into its numbers and qualifiers, which are
+// separated by dots, dashes and transitions between digits and letters
+func parseMaven(v string) []mavenItem {
+	var items []mavenItem
+	add := func(token string) {
+		if token == "" {
+			return
+		}
+		if token[0] >= '0' && token[0] <= '9' {
+			number := strings.TrimLeft(token, "0")
+			if number == "" {
+				number = "0"
+			}
+			items = append(items, mavenItem{number: number})
+			return
+		}
+		if alias, ok := mavenQualifierAliases[token]; ok {
+			token = alias
+		}
+		items = append(items, mavenItem{qualifier: token})
+	}
+
+	v = strings.ToLower(v)
+	start := 0
+	for i := 0; i < len(v); i++ {
+		switch {
+		case v[i] == '.' || v[i] == '-' || v[i] == '_':
+			add(v[start:i])
+			start = i + 1
+		case i > start && isDigit(v[i]) != isDigit(v[i-1]):
+			add(v[start:i])
+			start = i
+		}
+	}
+	add(v[start:])
+
+	// Trailing zeros and release qualifiers don't change the version, eg.
+	// "1.0.0" is "1" and "1.0-final" is "1"
+	for len(items) > 0 {
+		last := items[len(items)-1]
+		if last.number != "0" && (last.number != "" || last.qualifier != "") {
+			break
+		}
+		items = items[:len(items)-1]
+	}
+
+	return items
+}
+
+// mavenFlavor returns the suffix of versions like "28.1-jre" and
+// "30.0-android", which are different builds of the same version. Qualifiers
+// like "rc" or "beta" are not flavors.
+func mavenFlavor(v string) string {
+	i := strings.LastIndex(v, "-")
+	if i < 0 {
+		return ""
+	}
+	suffix := strings.ToLower(v[i+1:])
+	for j := 0; j < len(suffix); j++ {
+		if suffix[j] < 'a' || suffix[j] > 'z' {
+			return ""
+		}
+	}
+	if _, ok := mavenQualifiers[suffix]; ok {
+		return ""
+	}
+	if _, ok := mavenQualifierAliases[suffix]; ok {
+		return ""
+	}
+	return suffix
+}
+
+func isDigit(b byte) bool {
+	return b >= '0' && b <= '9'
+}
+
+// compareMaven compares Maven versions, in a simplified version of the order
+// of Maven's ComparableVersion
+func compareMaven(a, b string) (int, bool) {
+	ia, ib := parseMaven(a), parseMaven(b)
+	for i := 0; i < len(ia) || i < len(ib); i++ {
+		// Missing items are 0 for numbers, and a release for qualifiers
+		x, y := mavenItem{number: "0"}, mavenItem{number: "0"}
+		if i < len(ia) {
+			x = ia[i]
+		} else if ib[i].number == "" {
+			x = mavenItem{}
+		}
+		if i < len(ib) {
+			y = ib[i]
+		} else if ia[i].number == "" {
+			y = mavenItem{}
+		}
+
+		if c := compareMavenItem(x, y); c != 0 {
+			return c, true
+		}
+	}
+	return 0, true
+}
+
+func compareMavenItem(x, y mavenItem) int {
+	switch {
+	case x.number != "" && y.number != "":
+		if len(x.number) != len(y.number) {
+			return sign(len(x.number) - len(y.number))
+		}
+		return strings.Compare(x.number, y.number)
+	case x.number != "":
+		// Numbers are newer than qualifiers
+		return 1
+	case y.number != "":
+		return -1
+	}
+
+	ox, knownX := mavenQualifiers[x.qualifier]
+	oy, knownY := mavenQualifiers[y.qualifier]
+	switch {
+	case knownX && knownY:
+		return sign(ox - oy)
+	case knownX:
+		return -1
+	case knownY:
+		return 1
+	default:
+		return strings.Compare(x.qualifier, y.qualifier)
+	}
+}
+
+func sign(i int) int {
+	switch {
+	case i < 0:
+		return -1
+	case i > 0:
+		return 1
+	default:
+		return 0
+	}
+}
@@ -0,0 +1,148 @@
+package policy
+
+import (
+	"encoding/json"
+	"io/ioutil"
+	"strings"
+
+	"github.com/zegl/bazel_dependency_tools/internal/spdx"
+)
+
+// Status is the outcome of evaluating a license against a policy
+type Status int
+
+const (
+	Allowed Status = iota
+	Review
+	Forbidden
+)
+
+func (s Status) String() string {
+	switch s {
+	case Allowed:
+		return "ALLOWED"
+	case Review:
+		return "REVIEW"
+	default:
+		return "FORBIDDEN"
+	}
+}
+
+// Policy lists which licenses can be used. Licenses that are not listed, or
+// that could not be determined, require a review.
+type Policy struct {
+	Allowed   []string `json:"allowed"`
+	Review    []string `json:"review"`
+	Forbidden []string `json:"forbidden"`
+
+	// Exceptions are artifacts that are allowed regardless of their license
+	Exceptions []Exception `json:"exceptions"`
+}
+
+// Exception allows an artifact, either by its repository name or by its
+// Maven coordinate ("group:artifact" or "group:artifact:version"). If License
+// is set, the exception only applies as long as the artifact has that license.
+type Exception struct {
+	Artifact string `json:"artifact"`
+	License  string `json:"license"`
+	Reason   string `json:"reason"`
+}
+
+// Load reads the JSON policy file at path
+func Load(path string) (*Policy, error) {
+	data, err := ioutil.ReadFile(path)
+	if err != nil {
+		return nil, err
+	}
+
+	var p Policy
+	if err := json.Unmarshal(data, &p); err != nil {
+		return nil, err
+	}
+
+	return &p, nil
+}
+
+// Evaluate returns the status of an SPDX license expression. If the expression
+// has alternatives (OR) the best of them is used, and if multiple licenses
+// apply (AND) the worst of them is used.
+func (p *Policy) Evaluate(expr string) (Status, error) {
+	e, err := spdx.Parse(expr)
+	if err != nil {
+		return Review, err
+	}
+	return p.evaluate(e), nil
+}
+
+func (p *Policy) evaluate(e *spdx.Expression) Status {
+	switch e.Op {
+	case "OR":
+		best := Forbidden
+		for _, a := range e.Args {
+			if s := p.evaluate(a); s < best {
+				best = s
+			}
+		}
+		return best
+	case "AND":
+		worst := Allowed
+		for _, a := range e.Args {
+			if s := p.evaluate(a); s > worst {
+				worst = s
+			}
+		}
+		return worst
+	}
+
+	// A license with an exception can be listed explicitly, otherwise the
+	// status of the license is used
+	if e.Exception != "" {
+		if s, ok := p.lookup(e.String()); ok {
+			return s
+		}
+	}
+	if s, ok := p.lookup(e.License); ok {
+		return s
+	}
+	return Review
+}
+
+func (p *Policy) lookup(license string) (Status, bool) {
+	for _, list := range []struct {
+		licenses []string
+		status   Status
+	}{{p.Forbidden, Forbidden}, {p.Review, Review}, {p.Allowed, Allowed}} {
+		for _, l := range list.licenses {
+			if strings.EqualFold(l, license) {
+				return list.status, true
+			}
+		}
+	}
+	return Review, false
+}
+
+// Exempt returns the exception for an artifact with the license, if any
+func (p *Policy) Exempt(artifact, license string) (*Exception, bool) {
+	for i, e := range p.Exceptions {
+		if !matchesArtifact(e.Artifact, artifact) {
+			continue
+		}
+		if e.License != "" && !strings.EqualFold(e.License, license) {
+			continue
+		}
+		return &p.Exceptions[i], true
+	}
+	return nil, false
+}
+
+// matchesArtifact returns true if pattern is the artifact, or the artifact
+// without its version
+func matchesArtifact(pattern, artifact string) bool {
+	if pattern == artifact {
+		return true
+	}
+	if i := strings.LastIndex(artifact, ":"); i >= 0 && strings.Count(artifact, ":") >= 2 {
+		return pattern == artifact[:i]
+	}
+	return false
+}
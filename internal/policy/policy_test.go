@@ -0,0 +1,64 @@
+package policy
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestEvaluate(t *testing.T) {
+	p := &Policy{
+		Allowed:   []string{"Apache-2.0", "MIT", "GPL-2.0-only WITH Classpath-exception-2.0"},
+		Review:    []string{"EPL-1.0"},
+		Forbidden: []string{"GPL-2.0-only", "GPL-3.0-only"},
+	}
+
+	cases := []struct {
+		expr     string
+		expected Status
+	}{
+		{"Apache-2.0", Allowed},
+		{"mit", Allowed},
+		{"EPL-1.0", Review},
+		{"GPL-3.0-only", Forbidden},
+		{"ISC", Review},
+		{"GPL-3.0-only OR MIT", Allowed},
+		{"GPL-3.0-only OR EPL-1.0", Review},
+		{"MIT AND GPL-3.0-only", Forbidden},
+		{"GPL-2.0-only WITH Classpath-exception-2.0", Allowed},
+		{"CDDL-1.1 OR GPL-2.0-only WITH Classpath-exception-2.0", Allowed},
+		{"GPL-2.0-only WITH LLVM-exception", Forbidden},
+	}
+
+	for _, tc := range cases {
+		status, err := p.Evaluate(tc.expr)
+		assert.Nil(t, err, tc.expr)
+		assert.Equal(t, tc.expected, status, tc.expr)
+	}
+
+	status, err := p.Evaluate("MIT OR")
+	assert.NotNil(t, err)
+	assert.Equal(t, Review, status)
+}
+
+func TestExempt(t *testing.T) {
+	p := &Policy{
+		Exceptions: []Exception{
+			{Artifact: "com.example:internal", Reason: "Owned by us"},
+			{Artifact: "com_example_tool", License: "GPL-3.0-only", Reason: "Only used at build time"},
+		},
+	}
+
+	e, ok := p.Exempt("com.example:internal:1.0", "GPL-3.0-only")
+	assert.True(t, ok)
+	assert.Equal(t, "Owned by us", e.Reason)
+
+	_, ok = p.Exempt("com.example:internal-other:1.0", "GPL-3.0-only")
+	assert.False(t, ok)
+
+	_, ok = p.Exempt("com_example_tool", "GPL-3.0-only")
+	assert.True(t, ok)
+
+	_, ok = p.Exempt("com_example_tool", "AGPL-3.0-only")
+	assert.False(t, ok)
+}
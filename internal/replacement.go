@@ -5,3 +5,38 @@ type LineReplacement struct {
 	Line               int32
 	Find, Substitution string
 }
+
+// Services that archives are upgraded from
+const (
+	SourceGitHub = "github"
+	SourceGitLab = "gitlab"
+	SourceIndex  = "index"
+)
+
+// Upgrade is the outcome of checking a single dependency for newer versions.
+// NewVersion equals OldVersion if the dependency is already up to date, and Err
+// is set if the dependency could not be resolved.
+type Upgrade struct {
+	Rule         string // The repository rule, eg. "http_archive"
+	Name         string // The repository name
+	Coordinate   string // "group:artifact" for Maven artifacts, "host/owner/repo" for GitHub
+	Source       string // The service that hosts an archive, SourceGitHub, SourceGitLab or SourceIndex
+	OldVersion   string
+	NewVersion   string
+	Replacements []LineReplacement
+	Err          error
+
+	// ReleaseNotes and CompareURL describe the changes between the old and the
+	// new version, if available
+	ReleaseNotes string
+	CompareURL   string
+}
+
+// Replacements returns the replacements of all upgrades, in order.
+func Replacements(upgrades []Upgrade) []LineReplacement {
+	var res []LineReplacement
+	for _, u := range upgrades {
+		res = append(res, u.Replacements...)
+	}
+	return res
+}
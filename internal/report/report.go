@@ -0,0 +1,95 @@
+// Package report writes the license report in CSV, JSON or Markdown
+package report
+
+import (
+	"encoding/csv"
+	"encoding/json"
+	"fmt"
+	"io"
+	"strings"
+)
+
+// Row is the license of a single dependency
+type Row struct {
+	Kind       string `json:"kind"` // The repository rule, eg. "maven_jar"
+	Name       string `json:"name"`
+	Coordinate string `json:"coordinate,omitempty"`
+	Version    string `json:"version,omitempty"`
+	License    string `json:"license,omitempty"`
+	Source     string `json:"source,omitempty"` // Where the license was found, eg. "POM"
+	Notes      string `json:"notes,omitempty"`
+	Error      string `json:"error,omitempty"`
+}
+
+var header = []string{"kind", "name", "coordinate", "version", "license", "source", "notes", "error"}
+
+func (r Row) columns() []string {
+	return []string{r.Kind, r.Name, r.Coordinate, r.Version, r.License, r.Source, r.Notes, r.Error}
+}
+
+// formats are the supported formats, by their name
+var formats = map[string]func(io.Writer, []Row) error{
+	"csv":      WriteCSV,
+	"json":     WriteJSON,
+	"markdown": WriteMarkdown,
+}
+
+// Write writes the rows in the format, "csv", "json" or "markdown"
+func Write(w io.Writer, format string, rows []Row) error {
+	write, ok := formats[format]
+	if !ok {
+		return fmt.Errorf("unknown format %q, expected csv, json or markdown", format)
+	}
+	return write(w, rows)
+}
+
+// WriteCSV writes the rows as CSV, with a header
+func WriteCSV(w io.Writer, rows []Row) error {
+	cw := csv.NewWriter(w)
+	if err := cw.Write(header); err != nil {
+		return err
+	}
+	for _, r := range rows {
+		if err := cw.Write(r.columns()); err != nil {
+			return err
+		}
+	}
+	cw.Flush()
+	return cw.Error()
+}
+
+// WriteJSON writes the rows as a JSON array
+func WriteJSON(w io.Writer, rows []Row) error {
+	if rows == nil {
+		rows = []Row{}
+	}
+	enc := json.NewEncoder(w)
+	enc.SetIndent("", "  ")
+	return enc.Encode(rows)
+}
+
+// WriteMarkdown writes the rows as a Markdown table
+func WriteMarkdown(w io.Writer, rows []Row) error {
+	separator := make([]string, len(header))
+	for i := range separator {
+		separator[i] = "---"
+	}
+
+	lines := []string{markdownRow(header), markdownRow(separator)}
+	for _, r := range rows {
+		lines = append(lines, markdownRow(r.columns()))
+	}
+
+	_, err := io.WriteString(w, strings.Join(lines, "\n")+"\n")
+	return err
+}
+
+var markdownReplacer = strings.NewReplacer("\\", "\\\\", "|", "\\|", "\r\n", "<br>", "\n", "<br>")
+
+func markdownRow(columns []string) string {
+	escaped := make([]string, len(columns))
+	for i, c := range columns {
+		escaped[i] = markdownReplacer.Replace(c)
+	}
+	return "| " + strings.Join(escaped, " | ") + " |"
+}
@@ -0,0 +1,50 @@
+package report
+
+import (
+	"bytes"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+var testRows = []Row{
+	{Kind: "maven_jar", Name: "junit_junit", Coordinate: "junit:junit", Version: "4.12", License: "EPL-1.0", Source: "POM"},
+	{Kind: "maven_install", Name: "com.example:custom:1.0", Coordinate: "com.example:custom", Version: "1.0", License: "NOASSERTION (Example Corp License, Version 1 | 2)", Source: "parent POM"},
+	{Kind: "http_archive", Name: "tool", Error: "no license found"},
+}
+
+func TestWriteCSV(t *testing.T) {
+	var out bytes.Buffer
+	assert.Nil(t, Write(&out, "csv", testRows))
+	assert.Equal(t, `kind,name,coordinate,version,license,source,notes,error
+maven_jar,junit_junit,junit:junit,4.12,EPL-1.0,POM,,
+maven_install,com.example:custom:1.0,com.example:custom,1.0,"NOASSERTION (Example Corp License, Version 1 | 2)",parent POM,,
+http_archive,tool,,,,,,no license found
+`, out.String())
+}
+
+func TestWriteJSON(t *testing.T) {
+	var out bytes.Buffer
+	assert.Nil(t, Write(&out, "json", testRows[2:]))
+	assert.Equal(t, `[
+  {
+    "kind": "http_archive",
+    "name": "tool",
+    "error": "no license found"
+  }
+]
+`, out.String())
+}
+
+func TestWriteMarkdown(t *testing.T) {
+	var out bytes.Buffer
+	assert.Nil(t, Write(&out, "markdown", testRows[1:2]))
+	assert.Equal(t, `| kind | name | coordinate | version | license | source | notes | error |
+| --- | --- | --- | --- | --- | --- | --- | --- |
+| maven_install | com.example:custom:1.0 | com.example:custom | 1.0 | NOASSERTION (Example Corp License, Version 1 \| 2) | parent POM |  |  |
+`, out.String())
+}
+
+func TestWriteUnknownFormat(t *testing.T) {
+	assert.NotNil(t, Write(&bytes.Buffer{}, "xml", testRows))
+}
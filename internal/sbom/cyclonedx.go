@@ -0,0 +1,122 @@
+package sbom
+
+import (
+	"encoding/json"
+	"io"
+	"time"
+)
+
+type cycloneDXDocument struct {
+	BOMFormat    string               `json:"bomFormat"`
+	SpecVersion  string               `json:"specVersion"`
+	SerialNumber string               `json:"serialNumber"`
+	Version      int                  `json:"version"`
+	Metadata     cycloneDXMetadata    `json:"metadata"`
+	Components   []cycloneDXComponent `json:"components"`
+}
+
+type cycloneDXMetadata struct {
+	Timestamp string             `json:"timestamp"`
+	Tools     []cycloneDXTool    `json:"tools"`
+	Component cycloneDXComponent `json:"component"`
+}
+
+type cycloneDXTool struct {
+	Name string `json:"name"`
+}
+
+type cycloneDXComponent struct {
+	Type               string               `json:"type"`
+	BOMRef             string               `json:"bom-ref,omitempty"`
+	Group              string               `json:"group,omitempty"`
+	Name               string               `json:"name"`
+	Version            string               `json:"version,omitempty"`
+	Hashes             []cycloneDXHash      `json:"hashes,omitempty"`
+	Licenses           []cycloneDXLicense   `json:"licenses,omitempty"`
+	PURL               string               `json:"purl,omitempty"`
+	ExternalReferences []cycloneDXReference `json:"externalReferences,omitempty"`
+}
+
+type cycloneDXHash struct {
+	Alg     string `json:"alg"`
+	Content string `json:"content"`
+}
+
+type cycloneDXLicense struct {
+	Expression string                 `json:"expression,omitempty"`
+	License    *cycloneDXNamedLicense `json:"license,omitempty"`
+}
+
+type cycloneDXNamedLicense struct {
+	Name string `json:"name"`
+}
+
+type cycloneDXReference struct {
+	Type string `json:"type"`
+	URL  string `json:"url"`
+}
+
+// CycloneDX names of the hash algorithms
+var cycloneDXAlgorithms = map[string]string{
+	SHA1:   "SHA-1",
+	SHA256: "SHA-256",
+	SHA384: "SHA-384",
+	SHA512: "SHA-512",
+}
+
+// WriteCycloneDX writes the document as CycloneDX 1.4 JSON
+func WriteCycloneDX(w io.Writer, d *Document) error {
+	doc := cycloneDXDocument{
+		BOMFormat:    "CycloneDX",
+		SpecVersion:  "1.4",
+		SerialNumber: "urn:uuid:" + d.uuid(),
+		Version:      1,
+		Metadata: cycloneDXMetadata{
+			Timestamp: d.Created.UTC().Format(time.RFC3339),
+			Tools:     []cycloneDXTool{{Name: toolName}},
+			Component: cycloneDXComponent{Type: "application", Name: d.Name},
+		},
+		Components: []cycloneDXComponent{},
+	}
+
+	refs := make(map[string]bool)
+	for _, p := range d.Packages {
+		c := cycloneDXComponent{
+			Type:    "library",
+			Name:    p.Name,
+			Version: p.Version,
+			PURL:    p.PURL,
+		}
+		if p.Group != "" {
+			c.Group, c.Name = p.Group, p.Artifact
+		}
+
+		// bom-refs must be unique, the same artifact can be in multiple maven_installs
+		if p.PURL != "" && !refs[p.PURL] {
+			c.BOMRef = p.PURL
+			refs[p.PURL] = true
+		}
+
+		for _, h := range p.Hashes {
+			c.Hashes = append(c.Hashes, cycloneDXHash{Alg: cycloneDXAlgorithms[h.Algorithm], Content: h.Value})
+		}
+
+		// A component has either a single expression, or a list of licenses
+		switch {
+		case p.UnknownLicense != "":
+			c.Licenses = []cycloneDXLicense{{License: &cycloneDXNamedLicense{Name: p.UnknownLicense}}}
+		case p.License != "":
+			c.Licenses = []cycloneDXLicense{{Expression: p.License}}
+		}
+
+		if p.URL != "" {
+			c.ExternalReferences = []cycloneDXReference{{Type: "distribution", URL: p.URL}}
+		}
+
+		doc.Components = append(doc.Components, c)
+	}
+
+	enc := json.NewEncoder(w)
+	enc.SetIndent("", "  ")
+	return enc.Encode(doc)
+}
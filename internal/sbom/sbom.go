@@ -0,0 +1,109 @@
+// Package sbom writes Software Bills of Materials, in the SPDX and CycloneDX
+// JSON formats
+package sbom
+
+import (
+	"crypto/sha256"
+	"fmt"
+	"sort"
+	"strings"
+	"time"
+)
+
+// Hash algorithms, as named by SPDX
+const (
+	SHA1   = "SHA1"
+	SHA256 = "SHA256"
+	SHA384 = "SHA384"
+	SHA512 = "SHA512"
+)
+
+// Document is a Software Bill of Materials
+type Document struct {
+	Name     string // The name of the workspace
+	Created  time.Time
+	Packages []Package
+}
+
+// Package is a single external dependency
+type Package struct {
+	Rule     string // The repository rule, eg. "http_archive"
+	Name     string // The repository name, or the Maven coordinate of maven_install artifacts
+	Group    string // The Maven group, empty for other packages
+	Artifact string // The Maven artifact, empty for other packages
+	Version  string
+	PURL     string
+	URL      string // Where the package is downloaded from, empty if it's unknown
+	Hashes   []Hash
+
+	// License is the license as an SPDX expression, empty if it's unknown.
+	// UnknownLicense is the name of a license that couldn't be identified.
+	License        string
+	UnknownLicense string
+}
+
+// Hash is a checksum of the downloaded file
+type Hash struct {
+	Algorithm string // eg. SHA256
+	Value     string // The checksum in hex
+}
+
+// PURL formats a package URL, eg. "pkg:maven/junit/junit@4.12". name may
+// contain a namespace, separated by slashes. Qualifiers with an empty value
+// are left out.
+func PURL(typ, name, version string, qualifiers map[string]string) string {
+	var segments []string
+	for _, s := range strings.Split(name, "/") {
+		segments = append(segments, escape(s))
+	}
+
+	purl := "pkg:" + typ + "/" + strings.Join(segments, "/")
+	if version != "" {
+		purl += "@" + escape(version)
+	}
+
+	var keys []string
+	for k, v := range qualifiers {
+		if v != "" {
+			keys = append(keys, k)
+		}
+	}
+	sort.Strings(keys)
+	for i, k := range keys {
+		sep := "&"
+		if i == 0 {
+			sep = "?"
+		}
+		purl += sep + k + "=" + escape(qualifiers[k])
+	}
+
+	return purl
+}
+
+// escape percent-encodes all characters that are not unreserved
+func escape(s string) string {
+	var b strings.Builder
+	for _, c := range []byte(s) {
+		switch {
+		case 'a' <= c && c <= 'z', 'A' <= c && c <= 'Z', '0' <= c && c <= '9', c == '-', c == '.', c == '_', c == '~':
+			b.WriteByte(c)
+		default:
+			fmt.Fprintf(&b, "%%%02X", c)
+		}
+	}
+	return b.String()
+}
+
+// uuid returns a version 4 formatted UUID that is derived from the document,
+// so that the same document always gets the same identifier
+func (d *Document) uuid() string {
+	h := sha256.New()
+	fmt.Fprintf(h, "%s\n%s\n", d.Name, d.Created.Format(time.RFC3339))
+	for _, p := range d.Packages {
+		fmt.Fprintf(h, "%s\n", p.PURL)
+	}
+	b := h.Sum(nil)[:16]
+	b[6] = b[6]&0x0f | 0x40
+	b[8] = b[8]&0x3f | 0x80
+	return fmt.Sprintf("%x-%x-%x-%x-%x", b[0:4], b[4:6], b[6:8], b[8:10], b[10:16])
+}
@@ -0,0 +1,113 @@
+package sbom
+
+import (
+	"bytes"
+	"encoding/json"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+)
+
+var testDocument = &Document{
+	Name:    "example",
+	Created: time.Date(2020, 1, 2, 3, 4, 5, 0, time.UTC),
+	Packages: []Package{
+		{
+			Rule:    "http_archive",
+			Name:    "io_bazel_rules_go",
+			Version: "v0.20.2",
+			PURL:    "pkg:github/bazelbuild/rules_go@v0.20.2",
+			URL:     "https://github.com/bazelbuild/rules_go/releases/download/v0.20.2/rules_go-v0.20.2.tar.gz",
+			Hashes:  []Hash{{Algorithm: SHA256, Value: "b9aa86ec08a292b97ec4591cf578e020b35f98e12173bbd4a921f84f583aebd9"}},
+			License: "Apache-2.0",
+		},
+		{
+			Rule:           "maven_install",
+			Name:           "com.example:custom:1.0",
+			Group:          "com.example",
+			Artifact:       "custom",
+			Version:        "1.0",
+			PURL:           "pkg:maven/com.example/custom@1.0",
+			UnknownLicense: "Example Corp License",
+		},
+	},
+}
+
+func TestPURL(t *testing.T) {
+	assert.Equal(t, "pkg:maven/junit/junit@4.12", PURL("maven", "junit/junit", "4.12", nil))
+	assert.Equal(t, "pkg:maven/com.google.guava/guava@28.1-jre?classifier=sources&type=pom", PURL("maven", "com.google.guava/guava", "28.1-jre", map[string]string{"type": "pom", "classifier": "sources", "empty": ""}))
+	assert.Equal(t, "pkg:golang/github.com/google/go-github/v28@v28.1.1%2Bincompatible", PURL("golang", "github.com/google/go-github/v28", "v28.1.1+incompatible", nil))
+	assert.Equal(t, "pkg:generic/tool?download_url=https%3A%2F%2Fexample.com%2Ftool.zip", PURL("generic", "tool", "", map[string]string{"download_url": "https://example.com/tool.zip"}))
+}
+
+func TestWriteSPDX(t *testing.T) {
+	var out bytes.Buffer
+	assert.Nil(t, WriteSPDX(&out, testDocument))
+
+	var doc spdxDocument
+	assert.Nil(t, json.Unmarshal(out.Bytes(), &doc))
+	assert.Equal(t, "SPDX-2.3", doc.SPDXVersion)
+	assert.Equal(t, "2020-01-02T03:04:05Z", doc.CreationInfo.Created)
+	assert.Regexp(t, `^https://spdx.org/spdxdocs/example-[0-9a-f]{8}-[0-9a-f]{4}-4[0-9a-f]{3}-[89ab][0-9a-f]{3}-[0-9a-f]{12}$`, doc.DocumentNamespace)
+
+	assert.Len(t, doc.Packages, 3)
+	assert.Equal(t, spdxPackage{
+		Name:             "io_bazel_rules_go",
+		SPDXID:           "SPDXRef-Package-io-bazel-rules-go",
+		VersionInfo:      "v0.20.2",
+		DownloadLocation: "https://github.com/bazelbuild/rules_go/releases/download/v0.20.2/rules_go-v0.20.2.tar.gz",
+		Checksums:        []spdxChecksum{{Algorithm: "SHA256", ChecksumValue: "b9aa86ec08a292b97ec4591cf578e020b35f98e12173bbd4a921f84f583aebd9"}},
+		LicenseConcluded: "NOASSERTION",
+		LicenseDeclared:  "Apache-2.0",
+		CopyrightText:    "NOASSERTION",
+		ExternalRefs:     []spdxExternalRef{{ReferenceCategory: "PACKAGE-MANAGER", ReferenceType: "purl", ReferenceLocator: "pkg:github/bazelbuild/rules_go@v0.20.2"}},
+	}, doc.Packages[1])
+	assert.Equal(t, "com.example:custom", doc.Packages[2].Name)
+	assert.Equal(t, "NOASSERTION", doc.Packages[2].DownloadLocation)
+	assert.Equal(t, "NOASSERTION", doc.Packages[2].LicenseDeclared)
+	assert.Equal(t, "Declared license: Example Corp License", doc.Packages[2].LicenseComments)
+
+	assert.Equal(t, []spdxRelationship{
+		{SPDXElementID: "SPDXRef-DOCUMENT", RelationshipType: "DESCRIBES", RelatedSPDXElement: "SPDXRef-Workspace"},
+		{SPDXElementID: "SPDXRef-Workspace", RelationshipType: "DEPENDS_ON", RelatedSPDXElement: "SPDXRef-Package-io-bazel-rules-go"},
+		{SPDXElementID: "SPDXRef-Workspace", RelationshipType: "DEPENDS_ON", RelatedSPDXElement: "SPDXRef-Package-com.example-custom-1.0"},
+	}, doc.Relationships)
+}
+
+func TestWriteCycloneDX(t *testing.T) {
+	var out bytes.Buffer
+	assert.Nil(t, WriteCycloneDX(&out, testDocument))
+
+	var doc cycloneDXDocument
+	assert.Nil(t, json.Unmarshal(out.Bytes(), &doc))
+	assert.Equal(t, "1.4", doc.SpecVersion)
+	assert.Equal(t, "example", doc.Metadata.Component.Name)
+
+	assert.Equal(t, []cycloneDXComponent{
+		{
+			Type:               "library",
+			BOMRef:             "pkg:github/bazelbuild/rules_go@v0.20.2",
+			Name:               "io_bazel_rules_go",
+			Version:            "v0.20.2",
+			Hashes:             []cycloneDXHash{{Alg: "SHA-256", Content: "b9aa86ec08a292b97ec4591cf578e020b35f98e12173bbd4a921f84f583aebd9"}},
+			Licenses:           []cycloneDXLicense{{Expression: "Apache-2.0"}},
+			PURL:               "pkg:github/bazelbuild/rules_go@v0.20.2",
+			ExternalReferences: []cycloneDXReference{{Type: "distribution", URL: "https://github.com/bazelbuild/rules_go/releases/download/v0.20.2/rules_go-v0.20.2.tar.gz"}},
+		},
+		{
+			Type:     "library",
+			BOMRef:   "pkg:maven/com.example/custom@1.0",
+			Group:    "com.example",
+			Name:     "custom",
+			Version:  "1.0",
+			Licenses: []cycloneDXLicense{{License: &cycloneDXNamedLicense{Name: "Example Corp License"}}},
+			PURL:     "pkg:maven/com.example/custom@1.0",
+		},
+	}, doc.Components)
+
+	// The serial number is stable
+	var again bytes.Buffer
+	assert.Nil(t, WriteCycloneDX(&again, testDocument))
+	assert.Equal(t, out.String(), again.String())
+}
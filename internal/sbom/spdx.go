@@ -0,0 +1,152 @@
+package sbom
+
+import (
+	"encoding/json"
+	"fmt"
+	"io"
+	"regexp"
+	"time"
+)
+
+const toolName = "bazel_dependency_tools"
+
+type spdxDocument struct {
+	SPDXVersion       string             `json:"spdxVersion"`
+	DataLicense       string             `json:"dataLicense"`
+	SPDXID            string             `json:"SPDXID"`
+	Name              string             `json:"name"`
+	DocumentNamespace string             `json:"documentNamespace"`
+	CreationInfo      spdxCreationInfo   `json:"creationInfo"`
+	Packages          []spdxPackage      `json:"packages"`
+	Relationships     []spdxRelationship `json:"relationships"`
+}
+
+type spdxCreationInfo struct {
+	Created  string   `json:"created"`
+	Creators []string `json:"creators"`
+}
+
+type spdxPackage struct {
+	Name             string            `json:"name"`
+	SPDXID           string            `json:"SPDXID"`
+	VersionInfo      string            `json:"versionInfo,omitempty"`
+	DownloadLocation string            `json:"downloadLocation"`
+	FilesAnalyzed    bool              `json:"filesAnalyzed"`
+	Checksums        []spdxChecksum    `json:"checksums,omitempty"`
+	LicenseConcluded string            `json:"licenseConcluded"`
+	LicenseDeclared  string            `json:"licenseDeclared"`
+	LicenseComments  string            `json:"licenseComments,omitempty"`
+	CopyrightText    string            `json:"copyrightText"`
+	ExternalRefs     []spdxExternalRef `json:"externalRefs,omitempty"`
+}
+
+type spdxChecksum struct {
+	Algorithm     string `json:"algorithm"`
+	ChecksumValue string `json:"checksumValue"`
+}
+
+type spdxExternalRef struct {
+	ReferenceCategory string `json:"referenceCategory"`
+	ReferenceType     string `json:"referenceType"`
+	ReferenceLocator  string `json:"referenceLocator"`
+}
+
+type spdxRelationship struct {
+	SPDXElementID      string `json:"spdxElementId"`
+	RelationshipType   string `json:"relationshipType"`
+	RelatedSPDXElement string `json:"relatedSpdxElement"`
+}
+
+const noAssertion = "NOASSERTION"
+
+// Characters that are not allowed in SPDX identifiers
+var spdxIDRegex = regexp.MustCompile(`[^a-zA-Z0-9.-]+`)
+
+// WriteSPDX writes the document as SPDX 2.3 JSON. The workspace is a package
+// that depends on all other packages.
+func WriteSPDX(w io.Writer, d *Document) error {
+	doc := spdxDocument{
+		SPDXVersion:       "SPDX-2.3",
+		DataLicense:       "CC0-1.0",
+		SPDXID:            "SPDXRef-DOCUMENT",
+		Name:              d.Name,
+		DocumentNamespace: fmt.Sprintf("https://spdx.org/spdxdocs/%s-%s", spdxIDRegex.ReplaceAllString(d.Name, "-"), d.uuid()),
+		CreationInfo: spdxCreationInfo{
+			Created:  d.Created.UTC().Format(time.RFC3339),
+			Creators: []string{"Tool: " + toolName},
+		},
+	}
+
+	rootID := "SPDXRef-Workspace"
+	doc.Packages = append(doc.Packages, spdxPackage{
+		Name:             d.Name,
+		SPDXID:           rootID,
+		DownloadLocation: noAssertion,
+		LicenseConcluded: noAssertion,
+		LicenseDeclared:  noAssertion,
+		CopyrightText:    noAssertion,
+	})
+	doc.Relationships = append(doc.Relationships, spdxRelationship{
+		SPDXElementID:      doc.SPDXID,
+		RelationshipType:   "DESCRIBES",
+		RelatedSPDXElement: rootID,
+	})
+
+	ids := map[string]bool{rootID: true}
+	for _, p := range d.Packages {
+		id := "SPDXRef-Package-" + spdxIDRegex.ReplaceAllString(p.Name, "-")
+		for i := 2; ids[id]; i++ {
+			id = fmt.Sprintf("SPDXRef-Package-%s-%d", spdxIDRegex.ReplaceAllString(p.Name, "-"), i)
+		}
+		ids[id] = true
+
+		pkg := spdxPackage{
+			Name:             p.Name,
+			SPDXID:           id,
+			VersionInfo:      p.Version,
+			DownloadLocation: p.URL,
+			LicenseConcluded: noAssertion,
+			LicenseDeclared:  p.License,
+			CopyrightText:    noAssertion,
+		}
+		if p.Group != "" {
+			pkg.Name = p.Group + ":" + p.Artifact
+		}
+		if pkg.DownloadLocation == "" {
+			pkg.DownloadLocation = noAssertion
+		}
+
+		// Licenses that can't be identified can't be part of an expression
+		if pkg.LicenseDeclared == "" || p.UnknownLicense != "" {
+			pkg.LicenseDeclared = noAssertion
+		}
+		if p.UnknownLicense != "" {
+			pkg.LicenseComments = fmt.Sprintf("Declared license: %s", p.UnknownLicense)
+			if p.License != "" {
+				pkg.LicenseComments = fmt.Sprintf("Declared licenses: %s OR %s", p.License, p.UnknownLicense)
+			}
+		}
+
+		for _, h := range p.Hashes {
+			pkg.Checksums = append(pkg.Checksums, spdxChecksum{Algorithm: h.Algorithm, ChecksumValue: h.Value})
+		}
+		if p.PURL != "" {
+			pkg.ExternalRefs = append(pkg.ExternalRefs, spdxExternalRef{
+				ReferenceCategory: "PACKAGE-MANAGER",
+				ReferenceType:     "purl",
+				ReferenceLocator:  p.PURL,
+			})
+		}
+
+		doc.Packages = append(doc.Packages, pkg)
+		doc.Relationships = append(doc.Relationships, spdxRelationship{
+			SPDXElementID:      rootID,
+			RelationshipType:   "DEPENDS_ON",
+			RelatedSPDXElement: id,
+		})
+	}
+
+	enc := json.NewEncoder(w)
+	enc.SetIndent("", "  ")
+	return enc.Encode(doc)
+}
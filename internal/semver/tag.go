@@ -0,0 +1,138 @@
+package semver
+
+import (
+	"regexp"
+	"strings"
+
+	"github.com/blang/semver"
+)
+
+// Tag is a version as it's written in a tag, eg. "v1.2.3" or "release-1.2"
+type Tag struct {
+	Raw string
+
+	// Prefix is everything before the version, eg. "v"
+	Prefix string
+
+	// Version is the version as it's written, without the prefix, eg. "1.2"
+	Version string
+}
+
+var tagRegex = regexp.MustCompile(`^([^0-9]*)(\d+(?:\.\d+)*(?:[-+\.][0-9A-Za-z\.\-+]*)?)$`)
+
+// ParseTag splits a tag into its prefix and its version. Tags that don't look
+// like a version, such as commit SHAs, have an empty prefix and the full tag as
+// version.
+func ParseTag(raw string) Tag {
+	if m := tagRegex.FindStringSubmatch(raw); m != nil {
+		return Tag{Raw: raw, Prefix: m[1], Version: m[2]}
+	}
+	return Tag{Raw: raw, Version: raw}
+}
+
+// Semver parses the version of the tag, without its prefix
+func (t Tag) Semver() (*semver.Version, error) {
+	return NormalizeNew(t.Version)
+}
+
+// SamePrefix returns true if the tags are versions of the same thing, that is
+// they have the same prefix, ignoring case and a "v" before the version. A
+// repository with tags for multiple components like "foo-1.2" and "bar-2.0"
+// has newer versions of foo only in the tags that start with "foo-".
+func (t Tag) SamePrefix(other Tag) bool {
+	trim := func(prefix string) string {
+		return strings.TrimSuffix(strings.ToLower(prefix), "v")
+	}
+	return trim(t.Prefix) == trim(other.Prefix)
+}
+
+// Rewrite replaces all occurrences of the old tag in s with the new tag, in the
+// form that they are written in. Occurrences that include the prefix are
+// replaced with the new tag including its prefix, in the same casing as the
+// occurrence, and occurrences of the bare version are replaced with the bare
+// new version.
+func Rewrite(s string, old, new Tag) string {
+	if old.Version == "" {
+		return s
+	}
+
+	var sb strings.Builder
+	for i := 0; i < len(s); {
+		if old.Prefix != "" && isBoundaryBefore(s, i, true) && hasPrefixFold(s[i:], old.Prefix) {
+			occurrence := s[i : i+len(old.Prefix)]
+			rest := i + len(old.Prefix)
+			if strings.HasPrefix(s[rest:], old.Version) && isBoundaryAfter(s, rest+len(old.Version)) {
+				sb.WriteString(matchCase(occurrence, old.Prefix, new.Prefix))
+				sb.WriteString(new.Version)
+				i = rest + len(old.Version)
+				continue
+			}
+		}
+
+		if isBoundaryBefore(s, i, false) && strings.HasPrefix(s[i:], old.Version) && isBoundaryAfter(s, i+len(old.Version)) {
+			sb.WriteString(new.Version)
+			i += len(old.Version)
+			continue
+		}
+
+		sb.WriteByte(s[i])
+		i++
+	}
+
+	return sb.String()
+}
+
+func hasPrefixFold(s, prefix string) bool {
+	return len(s) >= len(prefix) && strings.EqualFold(s[:len(prefix)], prefix)
+}
+
+// matchCase returns prefix in the same casing as occurrence, which is a
+// possibly differently cased version of original
+func matchCase(occurrence, original, prefix string) string {
+	switch {
+	case occurrence == original:
+		return prefix
+	case strings.EqualFold(original, prefix):
+		return occurrence
+	case occurrence == strings.ToLower(occurrence):
+		return strings.ToLower(prefix)
+	case occurrence == strings.ToUpper(occurrence):
+		return strings.ToUpper(prefix)
+	default:
+		return prefix
+	}
+}
+
+func isDigit(b byte) bool {
+	return b >= '0' && b <= '9'
+}
+
+func isLetter(b byte) bool {
+	return (b >= 'a' && b <= 'z') || (b >= 'A' && b <= 'Z')
+}
+
+// isBoundaryBefore returns true if a version can start at position i of s, so
+// that "1.2" is not found in "11.2". If the version starts with a prefix, it
+// can't be preceded by a letter either.
+func isBoundaryBefore(s string, i int, withPrefix bool) bool {
+	if i == 0 {
+		return true
+	}
+	prev := s[i-1]
+	if isDigit(prev) || prev == '.' {
+		return false
+	}
+	return !withPrefix || !isLetter(prev)
+}
+
+// isBoundaryAfter returns true if a version can end at position i of s, so
+// that "1.2" is not found in "1.23" or "1.2.3"
+func isBoundaryAfter(s string, i int) bool {
+	if i >= len(s) {
+		return true
+	}
+	if isDigit(s[i]) {
+		return false
+	}
+	return !(s[i] == '.' && i+1 < len(s) && isDigit(s[i+1]))
+}
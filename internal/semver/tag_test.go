@@ -0,0 +1,57 @@
+package semver
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestParseTag(t *testing.T) {
+	assert.Equal(t, Tag{Raw: "v1.2.3", Prefix: "v", Version: "1.2.3"}, ParseTag("v1.2.3"))
+	assert.Equal(t, Tag{Raw: "1.2", Prefix: "", Version: "1.2"}, ParseTag("1.2"))
+	assert.Equal(t, Tag{Raw: "release-1.2.3-rc1", Prefix: "release-", Version: "1.2.3-rc1"}, ParseTag("release-1.2.3-rc1"))
+	assert.Equal(t, Tag{Raw: "d8f3ab8ca4a3b4e2a1a0e4a4c6e8a3b3e0a1e4c2", Version: "d8f3ab8ca4a3b4e2a1a0e4a4c6e8a3b3e0a1e4c2"}, ParseTag("d8f3ab8ca4a3b4e2a1a0e4a4c6e8a3b3e0a1e4c2"))
+}
+
+func TestTagSemver(t *testing.T) {
+	for _, raw := range []string{"1.2.3", "v1.2.3", "V1.2.3", "release-1.2.3", "foo-1.2.3", "release-v1.2"} {
+		v, err := ParseTag(raw).Semver()
+		assert.Nil(t, err, raw)
+		assert.Equal(t, uint64(1), v.Major, raw)
+		assert.Equal(t, uint64(2), v.Minor, raw)
+	}
+
+	_, err := ParseTag("d8f3ab8ca4a3b4e2a1a0e4a4c6e8a3b3e0a1e4c2").Semver()
+	assert.NotNil(t, err)
+}
+
+func TestTagSamePrefix(t *testing.T) {
+	assert.True(t, ParseTag("v1.2.3").SamePrefix(ParseTag("1.3.0")))
+	assert.True(t, ParseTag("V1.2.3").SamePrefix(ParseTag("v1.3.0")))
+	assert.True(t, ParseTag("release-1.2.3").SamePrefix(ParseTag("Release-v1.3.0")))
+	assert.False(t, ParseTag("foo-1.2.3").SamePrefix(ParseTag("bar-1.3.0")))
+	assert.False(t, ParseTag("foo-1.2.3").SamePrefix(ParseTag("1.3.0")))
+}
+
+func TestRewrite(t *testing.T) {
+	tests := []struct {
+		s, old, new, expected string
+	}{
+		// Tag and bare version in the same string
+		{"releases/download/v1.2.3/tool-1.2.3.tar.gz", "v1.2.3", "v1.3.0", "releases/download/v1.3.0/tool-1.3.0.tar.gz"},
+		// The prefix changed between the versions
+		{"releases/download/v1.2.3/tool-1.2.3.tar.gz", "v1.2.3", "release-1.3.0", "releases/download/release-1.3.0/tool-1.3.0.tar.gz"},
+		// The casing of the prefix is preserved
+		{"archive/V1.2.3/tool-v1.2.3", "V1.2.3", "V1.3.0", "archive/V1.3.0/tool-v1.3.0"},
+		// Versions are not padded, and partial matches are ignored
+		{"tool-1.2/tool-11.2-1.2.3-1.2.tar.gz", "v1.2", "v1.2.1", "tool-1.2.1/tool-11.2-1.2.3-1.2.1.tar.gz"},
+		// The new version contains the old version
+		{"v1.2/tool-1.2", "v1.2", "v1.2.1", "v1.2.1/tool-1.2.1"},
+		// Commits
+		{"archive/d8f3ab8ca4a3b4e2a1a0e4a4c6e8a3b3e0a1e4c2.tar.gz", "d8f3ab8ca4a3b4e2a1a0e4a4c6e8a3b3e0a1e4c2", "0123456789abcdef0123456789abcdef01234567", "archive/0123456789abcdef0123456789abcdef01234567.tar.gz"},
+	}
+
+	for _, tc := range tests {
+		assert.Equal(t, tc.expected, Rewrite(tc.s, ParseTag(tc.old), ParseTag(tc.new)), tc.s)
+	}
+}
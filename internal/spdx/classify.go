@@ -0,0 +1,152 @@
+package spdx
+
+import (
+	"strings"
+	"unicode"
+)
+
+// fingerprint identifies a license text by passages that are taken from the
+// license. A text matches if it contains all of the passages, after
+// normalizing case, punctuation and whitespace.
+type fingerprint struct {
+	id       string
+	passages []string
+}
+
+// fingerprints are checked in order, so a license whose passages also appear
+// in the text of another license must come after that license
+var fingerprints = []fingerprint{
+	{"Apache-2.0", []string{
+		"Apache License Version 2.0, January 2004",
+		"TERMS AND CONDITIONS FOR USE, REPRODUCTION, AND DISTRIBUTION",
+	}},
+	{"Apache-2.0", []string{
+		"Licensed under the Apache License, Version 2.0 (the \"License\")",
+		"you may not use this file except in compliance with the License",
+	}},
+	{"MIT", []string{
+		"Permission is hereby granted, free of charge, to any person obtaining a copy of this software",
+		"The above copyright notice and this permission notice shall be included in all copies or substantial portions of the Software.",
+	}},
+	{"MIT-0", []string{
+		"Permission is hereby granted, free of charge, to any person obtaining a copy of this software",
+		"to permit persons to whom the Software is furnished to do so.",
+		"THE SOFTWARE IS PROVIDED \"AS IS\", WITHOUT WARRANTY OF ANY KIND",
+	}},
+	{"BSD-3-Clause", []string{
+		"Redistribution and use in source and binary forms, with or without modification, are permitted provided that the following conditions are met",
+		"Redistributions of source code must retain the above copyright notice",
+		"Neither the name of",
+		"may be used to endorse or promote products derived from this software without specific prior written permission",
+	}},
+	{"BSD-2-Clause", []string{
+		"Redistribution and use in source and binary forms, with or without modification, are permitted provided that the following conditions are met",
+		"Redistributions of source code must retain the above copyright notice",
+		"Redistributions in binary form must reproduce the above copyright notice",
+	}},
+	{"ISC", []string{
+		"Permission to use, copy, modify, and/or distribute this software for any purpose with or without fee is hereby granted, provided that the above copyright notice and this permission notice appear in all copies.",
+	}},
+	{"ISC", []string{
+		"Permission to use, copy, modify, and distribute this software for any purpose with or without fee is hereby granted, provided that the above copyright notice and this permission notice appear in all copies.",
+	}},
+	{"0BSD", []string{
+		"Permission to use, copy, modify, and/or distribute this software for any purpose with or without fee is hereby granted.",
+		"THE SOFTWARE IS PROVIDED \"AS IS\" AND THE AUTHOR DISCLAIMS ALL WARRANTIES",
+	}},
+	{"MPL-2.0", []string{
+		"Mozilla Public License Version 2.0",
+		"Definitions",
+	}},
+	{"MPL-2.0", []string{
+		"This Source Code Form is subject to the terms of the Mozilla Public License, v. 2.0.",
+	}},
+	{"MPL-1.1", []string{
+		"MOZILLA PUBLIC LICENSE Version 1.1",
+	}},
+	{"EPL-2.0", []string{
+		"Eclipse Public License - v 2.0",
+		"THE ACCOMPANYING PROGRAM IS PROVIDED UNDER THE TERMS OF THIS ECLIPSE PUBLIC LICENSE",
+	}},
+	{"EPL-1.0", []string{
+		"Eclipse Public License - v 1.0",
+		"THE ACCOMPANYING PROGRAM IS PROVIDED UNDER THE TERMS OF THIS ECLIPSE PUBLIC LICENSE",
+	}},
+	{"AGPL-3.0-only", []string{
+		"GNU AFFERO GENERAL PUBLIC LICENSE Version 3, 19 November 2007",
+	}},
+	{"LGPL-3.0-only", []string{
+		"GNU LESSER GENERAL PUBLIC LICENSE Version 3, 29 June 2007",
+	}},
+	{"LGPL-2.1-only", []string{
+		"GNU LESSER GENERAL PUBLIC LICENSE Version 2.1, February 1999",
+	}},
+	{"GPL-3.0-only", []string{
+		"GNU GENERAL PUBLIC LICENSE Version 3, 29 June 2007",
+	}},
+	{"GPL-2.0-only", []string{
+		"GNU GENERAL PUBLIC LICENSE Version 2, June 1991",
+	}},
+	{"CDDL-1.1", []string{
+		"COMMON DEVELOPMENT AND DISTRIBUTION LICENSE (CDDL) Version 1.1",
+	}},
+	{"CDDL-1.0", []string{
+		"COMMON DEVELOPMENT AND DISTRIBUTION LICENSE (CDDL) Version 1.0",
+	}},
+	{"BSL-1.0", []string{
+		"Boost Software License - Version 1.0",
+		"Permission is hereby granted, free of charge, to any person or organization obtaining a copy of the software",
+	}},
+	{"Unlicense", []string{
+		"This is free and unencumbered software released into the public domain.",
+	}},
+	{"CC0-1.0", []string{
+		"CC0 1.0 Universal",
+		"Statement of Purpose",
+	}},
+	{"Zlib", []string{
+		"This software is provided 'as-is', without any express or implied warranty.",
+		"Altered source versions must be plainly marked as such, and must not be misrepresented as being the original software.",
+	}},
+	{"WTFPL", []string{
+		"DO WHAT THE FUCK YOU WANT TO PUBLIC LICENSE",
+	}},
+}
+
+func init() {
+	for i, f := range fingerprints {
+		for j, p := range f.passages {
+			fingerprints[i].passages[j] = normalizeText(p)
+		}
+	}
+}
+
+// Classify returns the SPDX identifier of a license text, such as the content
+// of a LICENSE file
+func Classify(text string) (string, bool) {
+	normalized := normalizeText(text)
+	for _, f := range fingerprints {
+		if containsAll(normalized, f.passages) {
+			return f.id, true
+		}
+	}
+	return "", false
+}
+
+func containsAll(text string, passages []string) bool {
+	for _, p := range passages {
+		if !strings.Contains(text, p) {
+			return false
+		}
+	}
+	return true
+}
+
+// normalizeText lowercases text and removes punctuation, comment markers and
+// line breaks, so that differently formatted copies of a license are equal
+func normalizeText(text string) string {
+	fields := strings.FieldsFunc(strings.ToLower(text), func(r rune) bool {
+		return !unicode.IsLetter(r) && !unicode.IsDigit(r)
+	})
+	return " " + strings.Join(fields, " ") + " "
+}
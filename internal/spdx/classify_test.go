@@ -0,0 +1,83 @@
+package spdx
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+const mitText = `MIT License
+
+Copyright (c) 2019 Example
+
+Permission is hereby granted, free of charge, to any person obtaining a copy
+of this software and associated documentation files (the "Software"), to deal
+in the Software without restriction, including without limitation the rights
+to use, copy, modify, merge, publish, distribute, sublicense, and/or sell
+copies of the Software, and to permit persons to whom the Software is
+furnished to do so, subject to the following conditions:
+
+The above copyright notice and this permission notice shall be included in all
+copies or substantial portions of the Software.
+
+THE SOFTWARE IS PROVIDED "AS IS", WITHOUT WARRANTY OF ANY KIND, EXPRESS OR
+IMPLIED, INCLUDING BUT NOT LIMITED TO THE WARRANTIES OF MERCHANTABILITY.
+`
+
+const bsd3Text = `Copyright (c) 2009 The Go Authors. All rights reserved.
+
+Redistribution and use in source and binary forms, with or without
+modification, are permitted provided that the following conditions are
+met:
+
+   * Redistributions of source code must retain the above copyright
+notice, this list of conditions and the following disclaimer.
+   * Redistributions in binary form must reproduce the above
+copyright notice, this list of conditions and the following disclaimer
+in the documentation and/or other materials provided with the
+distribution.
+   * Neither the name of Google Inc. nor the names of its
+contributors may be used to endorse or promote products derived from
+this software without specific prior written permission.
+`
+
+const iscText = `Copyright (c) 2015, Example
+
+Permission to use, copy, modify, and/or distribute this software for any
+purpose with or without fee is hereby granted, provided that the above
+copyright notice and this permission notice appear in all copies.
+
+THE SOFTWARE IS PROVIDED "AS IS" AND THE AUTHOR DISCLAIMS ALL WARRANTIES
+`
+
+func TestClassify(t *testing.T) {
+	id, ok := Classify(mitText)
+	assert.True(t, ok)
+	assert.Equal(t, "MIT", id)
+
+	id, ok = Classify(bsd3Text)
+	assert.True(t, ok)
+	assert.Equal(t, "BSD-3-Clause", id)
+
+	id, ok = Classify(iscText)
+	assert.True(t, ok)
+	assert.Equal(t, "ISC", id)
+
+	id, ok = Classify(`
+                                 Apache License
+                           Version 2.0, January 2004
+                        http://www.apache.org/licenses/
+
+   TERMS AND CONDITIONS FOR USE, REPRODUCTION, AND DISTRIBUTION
+`)
+	assert.True(t, ok)
+	assert.Equal(t, "Apache-2.0", id)
+
+	id, ok = Classify(`		    GNU GENERAL PUBLIC LICENSE
+		       Version 2, June 1991`)
+	assert.True(t, ok)
+	assert.Equal(t, "GPL-2.0-only", id)
+
+	_, ok = Classify("All rights reserved.")
+	assert.False(t, ok)
+}
@@ -0,0 +1,151 @@
+package spdx
+
+import (
+	"errors"
+	"fmt"
+	"strings"
+)
+
+// Expression is a parsed SPDX license expression. Op is "OR" or "AND" for
+// compound expressions, and empty for a single license.
+type Expression struct {
+	Op   string
+	Args []*Expression
+
+	// License and Exception are set for a single license, eg. "GPL-2.0-only"
+	// and "Classpath-exception-2.0" for "GPL-2.0-only WITH Classpath-exception-2.0"
+	License   string
+	Exception string
+}
+
+func (e *Expression) String() string {
+	if e.Op == "" {
+		if e.Exception != "" {
+			return e.License + " WITH " + e.Exception
+		}
+		return e.License
+	}
+
+	var args []string
+	for _, a := range e.Args {
+		s := a.String()
+		// AND binds tighter than OR
+		if a.Op != "" && a.Op != e.Op && e.Op == "AND" {
+			s = "(" + s + ")"
+		}
+		args = append(args, s)
+	}
+	return strings.Join(args, " "+e.Op+" ")
+}
+
+// Parse parses an SPDX license expression, eg. "MIT OR (Apache-2.0 AND BSD-3-Clause)".
+// License identifiers that are in the bundled license list are converted to
+// their canonical case.
+func Parse(expr string) (*Expression, error) {
+	p := &parser{tokens: tokenize(expr)}
+	if len(p.tokens) == 0 {
+		return nil, errors.New("empty license expression")
+	}
+
+	e, err := p.parseOr()
+	if err != nil {
+		return nil, fmt.Errorf("invalid license expression %q: %w", expr, err)
+	}
+	if p.pos != len(p.tokens) {
+		return nil, fmt.Errorf("invalid license expression %q: unexpected %q", expr, p.tokens[p.pos])
+	}
+	return e, nil
+}
+
+func tokenize(expr string) []string {
+	expr = strings.NewReplacer("(", " ( ", ")", " ) ").Replace(expr)
+	return strings.Fields(expr)
+}
+
+type parser struct {
+	tokens []string
+	pos    int
+}
+
+func (p *parser) peek() string {
+	if p.pos < len(p.tokens) {
+		return p.tokens[p.pos]
+	}
+	return ""
+}
+
+func (p *parser) next() string {
+	t := p.peek()
+	p.pos++
+	return t
+}
+
+func (p *parser) parseOr() (*Expression, error) {
+	return p.parseOp("OR", p.parseAnd)
+}
+
+func (p *parser) parseAnd() (*Expression, error) {
+	return p.parseOp("AND", p.parseWith)
+}
+
+// parseOp parses one or more operands separated by op
+func (p *parser) parseOp(op string, operand func() (*Expression, error)) (*Expression, error) {
+	first, err := operand()
+	if err != nil {
+		return nil, err
+	}
+
+	args := []*Expression{first}
+	for strings.EqualFold(p.peek(), op) {
+		p.next()
+		arg, err := operand()
+		if err != nil {
+			return nil, err
+		}
+		args = append(args, arg)
+	}
+
+	if len(args) == 1 {
+		return first, nil
+	}
+	return &Expression{Op: op, Args: args}, nil
+}
+
+func (p *parser) parseWith() (*Expression, error) {
+	token := p.next()
+	switch {
+	case token == "":
+		return nil, errors.New("unexpected end of expression")
+	case token == "(":
+		e, err := p.parseOr()
+		if err != nil {
+			return nil, err
+		}
+		if p.next() != ")" {
+			return nil, errors.New("missing )")
+		}
+		return e, nil
+	case token == ")" || isOperator(token):
+		return nil, fmt.Errorf("unexpected %q", token)
+	}
+
+	e := &Expression{License: token}
+	if l, ok := Lookup(token); ok {
+		e.License = l.ID
+	}
+
+	if strings.EqualFold(p.peek(), "WITH") {
+		p.next()
+		exception := p.next()
+		if exception == "" || exception == "(" || exception == ")" || isOperator(exception) {
+			return nil, errors.New("missing exception after WITH")
+		}
+		e.Exception = exception
+	}
+
+	return e, nil
+}
+
+func isOperator(token string) bool {
+	return strings.EqualFold(token, "AND") || strings.EqualFold(token, "OR") || strings.EqualFold(token, "WITH")
+}
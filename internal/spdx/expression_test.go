@@ -0,0 +1,34 @@
+package spdx
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestParse(t *testing.T) {
+	e, err := Parse("mit")
+	assert.Nil(t, err)
+	assert.Equal(t, &Expression{License: "MIT"}, e)
+
+	e, err = Parse("EPL-1.0 OR LGPL-2.1-only")
+	assert.Nil(t, err)
+	assert.Equal(t, "OR", e.Op)
+	assert.Len(t, e.Args, 2)
+
+	e, err = Parse("CDDL-1.1 OR GPL-2.0-only WITH Classpath-exception-2.0")
+	assert.Nil(t, err)
+	assert.Equal(t, &Expression{Op: "OR", Args: []*Expression{
+		{License: "CDDL-1.1"},
+		{License: "GPL-2.0-only", Exception: "Classpath-exception-2.0"},
+	}}, e)
+
+	e, err = Parse("MIT OR Apache-2.0 AND (BSD-3-Clause or ISC)")
+	assert.Nil(t, err)
+	assert.Equal(t, "MIT OR Apache-2.0 AND (BSD-3-Clause OR ISC)", e.String())
+
+	for _, invalid := range []string{"", "MIT OR", "(MIT", "MIT Apache-2.0", "AND MIT", "GPL-2.0-only WITH"} {
+		_, err := Parse(invalid)
+		assert.NotNil(t, err, invalid)
+	}
+}
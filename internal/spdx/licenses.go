@@ -0,0 +1,142 @@
+package spdx
+
+// License is a license from the SPDX license list
+type License struct {
+	ID   string
+	Name string
+	URLs []string
+}
+
+// licenses is a subset of the SPDX license list (https://spdx.org/licenses/),
+// with the licenses that are commonly used by dependencies
+var licenses = []License{
+	{"0BSD", "BSD Zero Clause License", []string{"http://landley.net/toybox/license.html"}},
+	{"AGPL-3.0-only", "GNU Affero General Public License v3.0 only", []string{"https://www.gnu.org/licenses/agpl-3.0.html"}},
+	{"AGPL-3.0-or-later", "GNU Affero General Public License v3.0 or later", nil},
+	{"Apache-1.1", "Apache License 1.1", []string{"http://apache.org/licenses/LICENSE-1.1", "https://opensource.org/licenses/Apache-1.1"}},
+	{"Apache-2.0", "Apache License 2.0", []string{"https://www.apache.org/licenses/LICENSE-2.0", "https://opensource.org/licenses/Apache-2.0"}},
+	{"BSD-2-Clause", "BSD 2-Clause \"Simplified\" License", []string{"https://opensource.org/licenses/BSD-2-Clause"}},
+	{"BSD-3-Clause", "BSD 3-Clause \"New\" or \"Revised\" License", []string{"https://opensource.org/licenses/BSD-3-Clause"}},
+	{"BSL-1.0", "Boost Software License 1.0", []string{"http://www.boost.org/LICENSE_1_0.txt", "https://opensource.org/licenses/BSL-1.0"}},
+	{"CC-BY-3.0", "Creative Commons Attribution 3.0 Unported", []string{"https://creativecommons.org/licenses/by/3.0/legalcode"}},
+	{"CC-BY-4.0", "Creative Commons Attribution 4.0 International", []string{"https://creativecommons.org/licenses/by/4.0/legalcode"}},
+	{"CC0-1.0", "Creative Commons Zero v1.0 Universal", []string{"https://creativecommons.org/publicdomain/zero/1.0/legalcode"}},
+	{"CDDL-1.0", "Common Development and Distribution License 1.0", []string{"https://opensource.org/licenses/cddl1"}},
+	{"CDDL-1.1", "Common Development and Distribution License 1.1", []string{"http://glassfish.java.net/public/CDDL+GPL_1_1.html", "https://javaee.github.io/glassfish/LICENSE"}},
+	{"EPL-1.0", "Eclipse Public License 1.0", []string{"http://www.eclipse.org/legal/epl-v10.html", "https://opensource.org/licenses/EPL-1.0"}},
+	{"EPL-2.0", "Eclipse Public License 2.0", []string{"https://www.eclipse.org/legal/epl-2.0", "https://www.opensource.org/licenses/EPL-2.0"}},
+	{"GPL-2.0-only", "GNU General Public License v2.0 only", []string{"https://www.gnu.org/licenses/old-licenses/gpl-2.0-standalone.html", "https://opensource.org/licenses/GPL-2.0"}},
+	{"GPL-2.0-or-later", "GNU General Public License v2.0 or later", nil},
+	{"GPL-3.0-only", "GNU General Public License v3.0 only", []string{"https://www.gnu.org/licenses/gpl-3.0-standalone.html", "https://opensource.org/licenses/GPL-3.0"}},
+	{"GPL-3.0-or-later", "GNU General Public License v3.0 or later", nil},
+	{"ISC", "ISC License", []string{"https://www.isc.org/licenses/", "https://opensource.org/licenses/ISC"}},
+	{"JSON", "JSON License", []string{"http://www.json.org/license.html"}},
+	{"LGPL-2.1-only", "GNU Lesser General Public License v2.1 only", []string{"https://www.gnu.org/licenses/old-licenses/lgpl-2.1-standalone.html", "https://opensource.org/licenses/LGPL-2.1"}},
+	{"LGPL-2.1-or-later", "GNU Lesser General Public License v2.1 or later", nil},
+	{"LGPL-3.0-only", "GNU Lesser General Public License v3.0 only", []string{"https://www.gnu.org/licenses/lgpl-3.0-standalone.html", "https://opensource.org/licenses/LGPL-3.0"}},
+	{"LGPL-3.0-or-later", "GNU Lesser General Public License v3.0 or later", nil},
+	{"MIT", "MIT License", []string{"https://opensource.org/licenses/MIT"}},
+	{"MIT-0", "MIT No Attribution", []string{"https://github.com/aws/mit-0"}},
+	{"MPL-1.1", "Mozilla Public License 1.1", []string{"http://www.mozilla.org/MPL/MPL-1.1.html", "https://opensource.org/licenses/MPL-1.1"}},
+	{"MPL-2.0", "Mozilla Public License 2.0", []string{"https://mozilla.org/MPL/2.0/", "https://opensource.org/licenses/MPL-2.0"}},
+	{"OpenSSL", "OpenSSL License", []string{"http://www.openssl.org/source/license.html"}},
+	{"PostgreSQL", "PostgreSQL License", []string{"http://www.postgresql.org/about/licence", "https://opensource.org/licenses/PostgreSQL"}},
+	{"Python-2.0", "Python License 2.0", []string{"https://opensource.org/licenses/Python-2.0"}},
+	{"Unicode-DFS-2016", "Unicode License Agreement - Data Files and Software (2016)", []string{"http://www.unicode.org/copyright.html"}},
+	{"Unlicense", "The Unlicense", []string{"https://unlicense.org/"}},
+	{"WTFPL", "Do What The F*ck You Want To Public License", []string{"http://www.wtfpl.net/about/"}},
+	{"Zlib", "zlib License", []string{"http://www.zlib.net/zlib_license.html", "http://www.gzip.org/zlib/zlib_license.html"}},
+}
+
+// aliases maps names that are used for licenses, in POMs and elsewhere, to
+// SPDX expressions. The names and IDs of all licenses are matched as well, so
+// they don't have to be repeated here.
+var aliases = map[string]string{
+	"Apache 2":                    "Apache-2.0",
+	"Apache 2.0":                  "Apache-2.0",
+	"Apache License, Version 2.0": "Apache-2.0",
+	"The Apache Software License, Version 2.0": "Apache-2.0",
+	"Apache Software License - Version 2.0":    "Apache-2.0",
+	"ASL 2.0":                                  "Apache-2.0",
+	"ASF 2.0":                                  "Apache-2.0",
+	"Apache License, Version 1.1":              "Apache-1.1",
+	"The Apache Software License, Version 1.1": "Apache-1.1",
+
+	"The MIT License":       "MIT",
+	"MIT/X11":               "MIT",
+	"Bouncy Castle Licence": "MIT",
+
+	"New BSD License":                      "BSD-3-Clause",
+	"Modified BSD License":                 "BSD-3-Clause",
+	"Revised BSD":                          "BSD-3-Clause",
+	"BSD 3-clause":                         "BSD-3-Clause",
+	"3-Clause BSD License":                 "BSD-3-Clause",
+	"The BSD 3-Clause License":             "BSD-3-Clause",
+	"Eclipse Distribution License - v 1.0": "BSD-3-Clause",
+	"EDL 1.0":                              "BSD-3-Clause",
+	"Go License":                           "BSD-3-Clause",
+	"Simplified BSD License":               "BSD-2-Clause",
+	"BSD 2-Clause":                         "BSD-2-Clause",
+	"2-Clause BSD License":                 "BSD-2-Clause",
+	"FreeBSD License":                      "BSD-2-Clause",
+
+	"Eclipse Public License - v 1.0": "EPL-1.0",
+	"Eclipse Public License v1.0":    "EPL-1.0",
+	"EPL 1.0":                        "EPL-1.0",
+	"Eclipse Public License - v 2.0": "EPL-2.0",
+	"Eclipse Public License v2.0":    "EPL-2.0",
+	"EPL 2.0":                        "EPL-2.0",
+
+	"GNU Lesser General Public License version 2.1":  "LGPL-2.1-only",
+	"GNU Lesser General Public License, version 2.1": "LGPL-2.1-only",
+	"LGPL 2.1": "LGPL-2.1-only",
+	"LGPLv2.1": "LGPL-2.1-only",
+	"GNU Lesser General Public License version 3":    "LGPL-3.0-only",
+	"GNU Lesser General Public License, version 3.0": "LGPL-3.0-only",
+	"LGPL 3.0":                              "LGPL-3.0-only",
+	"LGPLv3":                                "LGPL-3.0-only",
+	"GNU General Public License, version 2": "GPL-2.0-only",
+	"GPLv2":                                 "GPL-2.0-only",
+	"GNU General Public License, version 3": "GPL-3.0-only",
+	"GPLv3":                                 "GPL-3.0-only",
+	"GNU Affero General Public License, version 3": "AGPL-3.0-only",
+	"GPL2 w/ CPE": "GPL-2.0-only WITH Classpath-exception-2.0",
+	"GNU General Public License, version 2 with the GNU Classpath Exception": "GPL-2.0-only WITH Classpath-exception-2.0",
+	"CDDL + GPLv2 with classpath exception":                                  "CDDL-1.1 OR GPL-2.0-only WITH Classpath-exception-2.0",
+	"CDDL/GPLv2+CE":                                                          "CDDL-1.1 OR GPL-2.0-only WITH Classpath-exception-2.0",
+
+	"CDDL 1.0": "CDDL-1.0",
+	"COMMON DEVELOPMENT AND DISTRIBUTION LICENSE (CDDL) Version 1.0": "CDDL-1.0",
+	"CDDL 1.1": "CDDL-1.1",
+
+	"MPL 1.1": "MPL-1.1",
+	"MPL 2.0": "MPL-2.0",
+
+	"CC0": "CC0-1.0",
+	"Public Domain, per Creative Commons CC0": "CC0-1.0",
+	"Boost Software License 1.0":              "BSL-1.0",
+	"The JSON License":                        "JSON",
+	"The Unlicense":                           "Unlicense",
+}
+
+// urlAliases maps license URLs that are not in the SPDX license list to SPDX
+// expressions
+var urlAliases = map[string]string{
+	"http://www.apache.org/licenses/LICENSE-2.0.txt":          "Apache-2.0",
+	"http://repository.jboss.org/licenses/apache-2.0.txt":     "Apache-2.0",
+	"http://www.opensource.org/licenses/mit-license.php":      "MIT",
+	"http://www.opensource.org/licenses/bsd-license.php":      "BSD-2-Clause",
+	"http://www.eclipse.org/org/documents/edl-v10.php":        "BSD-3-Clause",
+	"https://golang.org/LICENSE":                              "BSD-3-Clause",
+	"http://www.eclipse.org/legal/epl-v20.html":               "EPL-2.0",
+	"http://www.gnu.org/licenses/lgpl-2.1.html":               "LGPL-2.1-only",
+	"http://www.gnu.org/licenses/old-licenses/lgpl-2.1.html":  "LGPL-2.1-only",
+	"http://www.gnu.org/licenses/lgpl.html":                   "LGPL-3.0-only",
+	"http://www.gnu.org/licenses/old-licenses/gpl-2.0.html":   "GPL-2.0-only",
+	"http://www.gnu.org/software/classpath/license.html":      "GPL-2.0-only WITH Classpath-exception-2.0",
+	"https://glassfish.dev.java.net/public/CDDL+GPL_1_1.html": "CDDL-1.1 OR GPL-2.0-only WITH Classpath-exception-2.0",
+	"https://oss.oracle.com/licenses/CDDL+GPL-1.1":            "CDDL-1.1 OR GPL-2.0-only WITH Classpath-exception-2.0",
+	"http://www.opensource.org/licenses/cddl1.php":            "CDDL-1.0",
+	"http://creativecommons.org/publicdomain/zero/1.0/":       "CC0-1.0",
+	"http://www.bouncycastle.org/licence.html":                "MIT",
+}
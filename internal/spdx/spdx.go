@@ -0,0 +1,93 @@
+package spdx
+
+import (
+	"strings"
+	"unicode"
+)
+
+var (
+	byID    = make(map[string]License)
+	byName  = make(map[string]string)
+	byURL   = make(map[string]string)
+	stopped = map[string]bool{"the": true, "version": true, "v": true, "license": true, "licence": true, "software": true, "public": true}
+)
+
+func init() {
+	for _, l := range licenses {
+		byID[strings.ToLower(l.ID)] = l
+		byName[nameKey(l.ID)] = l.ID
+		byName[nameKey(l.Name)] = l.ID
+		for _, u := range l.URLs {
+			byURL[urlKey(u)] = l.ID
+		}
+	}
+	for name, expr := range aliases {
+		byName[nameKey(name)] = expr
+	}
+	for u, expr := range urlAliases {
+		byURL[urlKey(u)] = expr
+	}
+}
+
+// Lookup returns the license with the SPDX identifier id, ignoring case
+func Lookup(id string) (License, bool) {
+	l, ok := byID[strings.ToLower(id)]
+	return l, ok
+}
+
+// Normalize returns the SPDX expression for a license with the name and url,
+// as they are written in for example a POM. The name is matched first, and
+// the url is only used if the name is unknown. ok is false if neither could
+// be matched.
+func Normalize(name, url string) (expr string, ok bool) {
+	if l, ok := Lookup(strings.TrimSpace(name)); ok {
+		return l.ID, true
+	}
+	if expr, ok := byName[nameKey(name)]; ok && name != "" {
+		return expr, true
+	}
+	if expr, ok := byURL[urlKey(url)]; ok && url != "" {
+		return expr, true
+	}
+	return "", false
+}
+
+// nameKey returns a simplified version of a license name, so that different
+// spellings of the same name are equal, eg. "The Apache License, Version 2.0"
+// and "Apache License 2.0"
+func nameKey(name string) string {
+	name = strings.ToLower(name)
+
+	fields := strings.FieldsFunc(name, func(r rune) bool {
+		return !unicode.IsLetter(r) && !unicode.IsDigit(r) && r != '.' && r != '+'
+	})
+
+	var res []string
+	for _, f := range fields {
+		f = strings.Trim(f, ".")
+		// "v2.0" is the same as "2.0"
+		if len(f) > 1 && f[0] == 'v' && unicode.IsDigit(rune(f[1])) {
+			f = f[1:]
+		}
+		if f == "" || stopped[f] {
+			continue
+		}
+		res = append(res, f)
+	}
+
+	return strings.Join(res, " ")
+}
+
+// urlKey returns a simplified version of a URL, without the scheme, "www."
+// and extensions that are used for the same document
+func urlKey(url string) string {
+	url = strings.ToLower(strings.TrimSpace(url))
+	url = strings.TrimPrefix(url, "http://")
+	url = strings.TrimPrefix(url, "https://")
+	url = strings.TrimPrefix(url, "www.")
+	url = strings.TrimSuffix(url, "/")
+	for _, ext := range []string{".txt", ".html", ".htm", ".php"} {
+		url = strings.TrimSuffix(url, ext)
+	}
+	return url
+}
@@ -0,0 +1,55 @@
+package spdx
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestNormalize(t *testing.T) {
+	cases := []struct {
+		name, url, expected string
+	}{
+		{"Apache License, Version 2.0", "", "Apache-2.0"},
+		{"The Apache Software License, Version 2.0", "http://www.apache.org/licenses/LICENSE-2.0.txt", "Apache-2.0"},
+		{"Apache License 2.0", "", "Apache-2.0"},
+		{"apache-2.0", "", "Apache-2.0"},
+		{"Apache 2", "", "Apache-2.0"},
+		{"", "https://www.apache.org/licenses/LICENSE-2.0", "Apache-2.0"},
+		{"The MIT License", "", "MIT"},
+		{"MIT License", "http://www.opensource.org/licenses/mit-license.php", "MIT"},
+		{"Eclipse Public License - v 1.0", "", "EPL-1.0"},
+		{"Eclipse Public License v1.0", "", "EPL-1.0"},
+		{"GNU Lesser General Public License version 2.1", "", "LGPL-2.1-only"},
+		{"New BSD License", "", "BSD-3-Clause"},
+		{"BSD 3-Clause \"New\" or \"Revised\" License", "", "BSD-3-Clause"},
+		{"CDDL + GPLv2 with classpath exception", "", "CDDL-1.1 OR GPL-2.0-only WITH Classpath-exception-2.0"},
+		{"Some Custom License", "http://www.eclipse.org/legal/epl-v10.html", "EPL-1.0"},
+	}
+
+	for _, tc := range cases {
+		expr, ok := Normalize(tc.name, tc.url)
+		assert.True(t, ok, tc.name)
+		assert.Equal(t, tc.expected, expr, tc.name)
+	}
+
+	_, ok := Normalize("Some Custom License", "http://example.com/LICENSE")
+	assert.False(t, ok)
+
+	_, ok = Normalize("", "")
+	assert.False(t, ok)
+
+	// "BSD" is ambiguous
+	_, ok = Normalize("BSD License", "")
+	assert.False(t, ok)
+}
+
+func TestLookup(t *testing.T) {
+	l, ok := Lookup("mit")
+	assert.True(t, ok)
+	assert.Equal(t, "MIT", l.ID)
+	assert.Equal(t, "MIT License", l.Name)
+
+	_, ok = Lookup("Apache License, Version 2.0")
+	assert.False(t, ok)
+}
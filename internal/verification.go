@@ -0,0 +1,17 @@
+package internal
+
+import "errors"
+
+// ErrNoChecksum is reported for dependencies that don't declare a checksum
+var ErrNoChecksum = errors.New("no checksum declared")
+
+// Verification is the outcome of verifying the declared checksum of a single
+// dependency. Err is set if the checksum doesn't match, or if it could not be
+// verified.
+type Verification struct {
+	Rule     string // The repository rule, eg. "http_archive"
+	Name     string // The repository name
+	Filename string
+	Line     int32
+	Err      error
+}
@@ -0,0 +1,239 @@
+package main
+
+import (
+	"fmt"
+	"io"
+	"log"
+	"strings"
+
+	"go.starlark.net/syntax"
+
+	"github.com/zegl/bazel_dependency_tools/http_archive"
+	"github.com/zegl/bazel_dependency_tools/internal/download"
+	"github.com/zegl/bazel_dependency_tools/internal/github"
+	"github.com/zegl/bazel_dependency_tools/internal/policy"
+	"github.com/zegl/bazel_dependency_tools/internal/report"
+	"github.com/zegl/bazel_dependency_tools/internal/sbom"
+	"github.com/zegl/bazel_dependency_tools/maven_jar"
+	"github.com/zegl/bazel_dependency_tools/parse"
+)
+
+// licenseResult is the license of a single artifact, and the position of the
+// rule that declares it
+type licenseResult struct {
+	Rule     string
+	Name     string // The repository name, or the coordinate of maven_install artifacts
+	Artifact string // The Maven coordinate of maven_jar and maven_install artifacts, "group:artifact:version"
+	Filename string
+	Line     int32
+	License  maven_jar.PomLicense
+	Files    map[string][]byte // The license and NOTICE files of archives
+	Err      error
+}
+
+func collectLicenses(workspace, prefixFilter string, downloader *download.Downloader, gitHub github.Hosts) []licenseResult {
+	var results []licenseResult
+
+	callFuncs := map[string]parse.FuncHook{
+		"maven_jar": func(s *syntax.CallExpr, namePrefixFilter string, workspacePath string) error {
+			name, artifact, license, err := maven_jar.License(s, namePrefixFilter)
+			if err == maven_jar.ErrSkipped {
+				return nil
+			}
+			start, _ := s.Span()
+			results = append(results, licenseResult{Rule: "maven_jar", Name: name, Artifact: artifact, Filename: start.Filename(), Line: start.Line, License: license, Err: err})
+			return nil
+		},
+		"maven_install": func(s *syntax.CallExpr, namePrefixFilter string, workspacePath string) error {
+			licenses, err := maven_jar.LicenseMavenInstall(s, namePrefixFilter, workspacePath)
+			if err == maven_jar.ErrSkipped {
+				return nil
+			}
+			start, _ := s.Span()
+			if err != nil {
+				results = append(results, licenseResult{Rule: "maven_install", Name: callName(s), Filename: start.Filename(), Line: start.Line, Err: err})
+				return nil
+			}
+			for _, l := range licenses {
+				results = append(results, licenseResult{Rule: "maven_install", Name: l.Art, Artifact: l.Art, Filename: start.Filename(), Line: start.Line, License: l.License, Err: l.Err})
+			}
+			return nil
+		},
+		"http_archive":   archiveLicenseHook("http_archive", http_archive.License, &results, downloader, gitHub),
+		"git_repository": archiveLicenseHook("git_repository", http_archive.GitRepositoryLicense, &results, downloader, gitHub),
+	}
+	parse.ParseWorkspace(workspace, prefixFilter, callFuncs)
+
+	return results
+}
+
+type archiveLicenseFunc func(e *syntax.CallExpr, namePrefixFilter string, downloader *download.Downloader, gitHub github.Hosts) (string, *http_archive.ArchiveLicense, error)
+
+// archiveLicenseHook creates a hook that appends the licenses of archives to results
+func archiveLicenseHook(rule string, fn archiveLicenseFunc, results *[]licenseResult, downloader *download.Downloader, gitHub github.Hosts) parse.FuncHook {
+	return func(s *syntax.CallExpr, namePrefixFilter string, workspacePath string) error {
+		name, license, err := fn(s, namePrefixFilter, downloader, gitHub)
+		if license == nil && err == nil {
+			return nil
+		}
+		start, _ := s.Span()
+		r := licenseResult{Rule: rule, Name: name, Filename: start.Filename(), Line: start.Line, Err: err}
+		if license != nil {
+			r.Files = license.Files
+			if err == nil {
+				r.License = maven_jar.PomLicense{SPDX: maven_jar.LIC(license.SPDX), Source: license.Source}
+			}
+		}
+		*results = append(*results, r)
+		return nil
+	}
+}
+
+// callName returns the value of the name argument of a repository rule
+func callName(e *syntax.CallExpr) string {
+	for _, arg := range e.Args {
+		if binExp, ok := arg.(*syntax.BinaryExpr); ok && binExp.Op == syntax.EQ {
+			if xIdent, ok := binExp.X.(*syntax.Ident); ok && xIdent.Name == "name" {
+				if rhs, ok := binExp.Y.(*syntax.Literal); ok {
+					return rhs.Value.(string)
+				}
+			}
+		}
+	}
+	return ""
+}
+
+// findLicenses writes a report of the licenses of all dependencies, in the
+// "csv", "json" or "markdown" format
+func findLicenses(w io.Writer, format, workspace, prefixFilter string, downloader *download.Downloader, gitHub github.Hosts) error {
+	_, packages := collectPackages(workspace, prefixFilter)
+	results := collectLicenses(workspace, prefixFilter, downloader, gitHub)
+	if err := report.Write(w, format, licenseRows(results, packages)); err != nil {
+		return err
+	}
+
+	// Summarize the failures after the report, so that they're not lost in the log
+	var failed []licenseResult
+	for _, r := range results {
+		if r.Err != nil {
+			failed = append(failed, r)
+		}
+	}
+	if len(failed) > 0 {
+		log.Printf("Failed to find the license of %d dependencies:", len(failed))
+		for _, r := range failed {
+			log.Printf("  %s %s: %s", r.Rule, r.Name, r.Err)
+		}
+	}
+	return nil
+}
+
+// licenseRows converts the licenses to report rows, with the coordinate and
+// the version of the matching packages
+func licenseRows(results []licenseResult, packages []sbom.Package) []report.Row {
+	type key struct{ rule, name string }
+	byName := make(map[key]sbom.Package)
+	for _, p := range packages {
+		byName[key{p.Rule, p.Name}] = p
+	}
+
+	var rows []report.Row
+	for _, r := range results {
+		row := report.Row{
+			Kind: r.Rule,
+			Name: r.Name,
+		}
+		if p, ok := byName[key{r.Rule, r.Name}]; ok {
+			row.Coordinate = packageCoordinate(p)
+			row.Version = p.Version
+		}
+		if r.Err != nil {
+			row.Error = r.Err.Error()
+		} else {
+			row.License = r.License.String()
+			row.Source = r.License.Source
+			row.Notes = r.License.Notes()
+		}
+		rows = append(rows, row)
+	}
+	return rows
+}
+
+// packageCoordinate returns "group:artifact" for Maven artifacts,
+// "github.com/owner/repo" for GitHub repositories and the import path for Go
+// modules, or an empty string for other packages
+func packageCoordinate(p sbom.Package) string {
+	if p.Group != "" {
+		return p.Group + ":" + p.Artifact
+	}
+
+	name := strings.SplitN(strings.SplitN(p.PURL, "@", 2)[0], "?", 2)[0]
+	switch {
+	case strings.HasPrefix(name, "pkg:github/"):
+		return "github.com/" + strings.TrimPrefix(name, "pkg:github/")
+	case strings.HasPrefix(name, "pkg:golang/"):
+		return strings.TrimPrefix(name, "pkg:golang/")
+	default:
+		return ""
+	}
+}
+
+// checkLicensePolicy evaluates the licenses against the policy, and prints
+// all artifacts that are not allowed. It returns false if any of them has a
+// forbidden license.
+func checkLicensePolicy(w io.Writer, results []licenseResult, p *policy.Policy) bool {
+	ok := true
+
+	for _, r := range results {
+		status, reason := evaluateLicense(r, p)
+		if status == policy.Allowed {
+			continue
+		}
+		if status == policy.Forbidden {
+			ok = false
+		}
+		fmt.Fprintf(w, "%s:%d: %s %s %s: %s\n", r.Filename, r.Line, status, r.Rule, r.Name, reason)
+	}
+
+	return ok
+}
+
+// evaluateLicense returns the status of the license of an artifact, and a
+// description of why it has that status
+func evaluateLicense(r licenseResult, p *policy.Policy) (policy.Status, string) {
+	if r.Err != nil {
+		return policy.Review, r.Err.Error()
+	}
+
+	// Exceptions match either the repository name or the Maven coordinate
+	for _, artifact := range []string{r.Name, r.Artifact} {
+		if artifact == "" {
+			continue
+		}
+		if e, ok := p.Exempt(artifact, string(r.License.SPDX)); ok {
+			return policy.Allowed, e.Reason
+		}
+	}
+
+	// Unknown licenses can't be evaluated
+	if r.License.SPDX == "" {
+		return policy.Review, r.License.String()
+	}
+
+	status, err := p.Evaluate(string(r.License.SPDX))
+	if err != nil {
+		return policy.Review, err.Error()
+	}
+
+	// The artifact can also be used under a license that is unknown
+	if len(r.License.Unknown) > 0 && status == policy.Forbidden {
+		status = policy.Review
+	}
+
+	// A guessed license may not be the license of this version
+	if r.License.GuessedFrom != "" && status == policy.Allowed {
+		return policy.Review, fmt.Sprintf("%s, guessed from version %s", r.License, r.License.GuessedFrom)
+	}
+
+	return status, r.License.String()
+}
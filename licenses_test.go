@@ -0,0 +1,69 @@
+package main
+
+import (
+	"bytes"
+	"errors"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+
+	"github.com/zegl/bazel_dependency_tools/internal/policy"
+	"github.com/zegl/bazel_dependency_tools/internal/report"
+	"github.com/zegl/bazel_dependency_tools/maven_jar"
+)
+
+func TestCheckLicensePolicy(t *testing.T) {
+	p := &policy.Policy{
+		Allowed:   []string{"Apache-2.0", "MIT"},
+		Review:    []string{"EPL-1.0"},
+		Forbidden: []string{"GPL-3.0-only"},
+		Exceptions: []policy.Exception{
+			{Artifact: "com.example:tool", Reason: "Only used in tests"},
+			{Artifact: "com.example:legacy", Reason: "Being replaced"},
+			{Artifact: "com_example_build", Reason: "Only used at build time"},
+		},
+	}
+
+	results := []licenseResult{
+		{Rule: "maven_jar", Name: "com_google_guava_guava", Filename: "WORKSPACE", Line: 3, License: maven_jar.PomLicense{SPDX: "Apache-2.0"}},
+		{Rule: "maven_jar", Name: "junit_junit", Filename: "WORKSPACE", Line: 9, License: maven_jar.PomLicense{SPDX: "EPL-1.0"}},
+		{Rule: "maven_install", Name: "com.example:gpl:1.0", Filename: "WORKSPACE", Line: 15, License: maven_jar.PomLicense{SPDX: "GPL-3.0-only"}},
+		{Rule: "maven_install", Name: "com.example:dual:1.0", Filename: "WORKSPACE", Line: 15, License: maven_jar.PomLicense{SPDX: "GPL-3.0-only OR MIT"}},
+		{Rule: "maven_install", Name: "com.example:tool:1.0", Filename: "WORKSPACE", Line: 15, License: maven_jar.PomLicense{SPDX: "GPL-3.0-only"}},
+		{Rule: "maven_install", Name: "com.example:custom:1.0", Filename: "WORKSPACE", Line: 15, License: maven_jar.PomLicense{Unknown: []string{"Example Corp License"}}},
+		{Rule: "maven_install", Name: "com.example:missing:1.0", Filename: "WORKSPACE", Line: 15, Err: errors.New("no license found")},
+		{Rule: "maven_install", Name: "com.example:guessed:1.0", Filename: "WORKSPACE", Line: 15, License: maven_jar.PomLicense{SPDX: "MIT", GuessedFrom: "2.0"}},
+		{Rule: "maven_jar", Name: "com_example_legacy", Artifact: "com.example:legacy:1.0", Filename: "WORKSPACE", Line: 20, License: maven_jar.PomLicense{SPDX: "GPL-3.0-only"}},
+		{Rule: "maven_jar", Name: "com_example_build", Artifact: "com.example:build:1.0", Filename: "WORKSPACE", Line: 25, License: maven_jar.PomLicense{SPDX: "GPL-3.0-only"}},
+	}
+
+	var out bytes.Buffer
+	assert.False(t, checkLicensePolicy(&out, results, p))
+	assert.Equal(t, `WORKSPACE:9: REVIEW maven_jar junit_junit: EPL-1.0
+WORKSPACE:15: FORBIDDEN maven_install com.example:gpl:1.0: GPL-3.0-only
+WORKSPACE:15: REVIEW maven_install com.example:custom:1.0: LicenseRef-Example-Corp-License
+WORKSPACE:15: REVIEW maven_install com.example:missing:1.0: no license found
+WORKSPACE:15: REVIEW maven_install com.example:guessed:1.0: MIT, guessed from version 2.0
+`, out.String())
+
+	out.Reset()
+	assert.True(t, checkLicensePolicy(&out, results[:2], p))
+}
+
+func TestLicenseRows(t *testing.T) {
+	_, packages := collectPackages("testdata/sbom_WORKSPACE", "")
+
+	rows := licenseRows([]licenseResult{
+		{Rule: "http_archive", Name: "io_bazel_rules_go", License: maven_jar.PomLicense{SPDX: "Apache-2.0", Source: "LICENSE file"}},
+		{Rule: "maven_jar", Name: "junit_junit", License: maven_jar.PomLicense{SPDX: "EPL-1.0", Source: maven_jar.LicenseSourcePOM}},
+		{Rule: "maven_install", Name: "com.google.guava:failureaccess:1.0.1", License: maven_jar.PomLicense{SPDX: "Apache-2.0", Source: maven_jar.LicenseSourceParentPOM}},
+		{Rule: "git_repository", Name: "bazel_skylib", Err: errors.New("no license found")},
+	}, packages)
+
+	assert.Equal(t, []report.Row{
+		{Kind: "http_archive", Name: "io_bazel_rules_go", Coordinate: "github.com/bazelbuild/rules_go", Version: "v0.20.2", License: "Apache-2.0", Source: "LICENSE file"},
+		{Kind: "maven_jar", Name: "junit_junit", Coordinate: "junit:junit", Version: "4.12", License: "EPL-1.0", Source: "POM"},
+		{Kind: "maven_install", Name: "com.google.guava:failureaccess:1.0.1", Coordinate: "com.google.guava:failureaccess", Version: "1.0.1", License: "Apache-2.0", Source: "parent POM"},
+		{Kind: "git_repository", Name: "bazel_skylib", Coordinate: "github.com/bazelbuild/bazel-skylib", Version: "1.0.0", Error: "no license found"},
+	}, rows)
+}
@@ -0,0 +1,56 @@
+package maven_jar
+
+import (
+	"go.starlark.net/syntax"
+
+	"github.com/zegl/bazel_dependency_tools/parse"
+)
+
+// centralRepository is Maven Central, the default repository of maven_jar
+const centralRepository = "https://repo1.maven.org/maven2"
+
+// attributes are the attributes of a maven_jar that are relevant when
+// upgrading, verifying or finding the license of it
+type attributes struct {
+	name       string
+	artifact   *parse.MultiPosLiteral
+	sha1       *syntax.Literal
+	sha256     *syntax.Literal
+	repository string
+}
+
+func parseAttributes(e *syntax.CallExpr) attributes {
+	attrs := attributes{repository: centralRepository}
+
+	for _, arg := range e.Args {
+		if binExp, ok := arg.(*syntax.BinaryExpr); ok && binExp.Op == syntax.EQ {
+			if xIdent, ok := binExp.X.(*syntax.Ident); ok {
+				switch xIdent.Name {
+				case "name":
+					if rhs, ok := binExp.Y.(*syntax.Literal); ok {
+						attrs.name = rhs.Value.(string)
+					}
+				case "artifact":
+					switch binExp.Y.(type) {
+					case *syntax.Literal, *parse.MultiPosLiteral:
+						attrs.artifact = parse.ToMultiPosLiteral(binExp.Y)
+					}
+				case "sha1":
+					if rhs, ok := binExp.Y.(*syntax.Literal); ok {
+						attrs.sha1 = rhs
+					}
+				case "sha256":
+					if rhs, ok := binExp.Y.(*syntax.Literal); ok {
+						attrs.sha256 = rhs
+					}
+				case "repository":
+					if rhs, ok := binExp.Y.(*syntax.Literal); ok {
+						attrs.repository = rhs.Value.(string)
+					}
+				}
+			}
+		}
+	}
+
+	return attrs
+}
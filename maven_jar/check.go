@@ -16,6 +16,9 @@ import (
 	"github.com/zegl/bazel_dependency_tools/parse"
 )
 
+// NewestVersionResolver returns the version that an artifact should be
+// upgraded to, and the sha1 of its jar. If the sha1 is empty it's fetched from
+// the repository of the maven_jar when needed.
 type NewestVersionResolver func(coordinate string) (version, sha1 string, err error)
 
 type Meta struct {
@@ -31,21 +34,37 @@ type Meta struct {
 func NewestAvailable(coordinate string) (string, string, error) {
 	xyz := strings.Split(coordinate, ":")
 
-	resp, err := http.Get(fmt.Sprintf("https://repo1.maven.org/maven2/%s/%s/maven-metadata.xml", strings.ReplaceAll(xyz[0], ".", "/"), xyz[1]))
+	newestVersion, err := newestVersion(centralRepository, xyz[0], xyz[1])
 	if err != nil {
-		return "", "", fmt.Errorf("failed to fetch from repo1.maven.org: %w", err)
+		return "", "", err
+	}
+
+	sha1, err := mavenSha1(centralRepository, xyz[0], xyz[1], newestVersion)
+	if err != nil {
+		return "", "", fmt.Errorf("failed to fetch sha1: %w", err)
+	}
+
+	return newestVersion, sha1, nil
+}
+
+// newestVersion returns the newest version of an artifact in the repository,
+// as listed by its maven-metadata.xml
+func newestVersion(repository, x, y string) (string, error) {
+	resp, err := http.Get(fmt.Sprintf("%s/%s/%s/maven-metadata.xml", strings.TrimSuffix(repository, "/"), strings.ReplaceAll(x, ".", "/"), y))
+	if err != nil {
+		return "", fmt.Errorf("failed to fetch from %s: %w", repository, err)
 	}
 	defer resp.Body.Close()
 
 	allData, err := ioutil.ReadAll(resp.Body)
 	if err != nil {
-		return "", "", fmt.Errorf("reading XML response from maven failed: %w", err)
+		return "", fmt.Errorf("reading XML response from maven failed: %w", err)
 	}
 
 	var meta Meta
 	err = xml.Unmarshal(allData, &meta)
 	if err != nil {
-		return "", "", fmt.Errorf("unmarshal maven XML failed: %w", err)
+		return "", fmt.Errorf("unmarshal maven XML failed: %w", err)
 	}
 
 	// Find the newest version available
@@ -61,19 +80,29 @@ func NewestAvailable(coordinate string) (string, string, error) {
 		}
 	}
 
-	sha1, err := mavenCentralSha1(xyz[0], xyz[1], newestVersion.String())
-	if err != nil {
-		return "", "", fmt.Errorf("failed to fetch sha1: %w", err)
-	}
+	return newestVersion.String(), nil
+}
 
-	return newestVersion.String(), sha1, nil
+// FixedVersionResolver returns a resolver that upgrades the artifacts in
+// fixes, by their "group:artifact", to the version in fixes. Other artifacts
+// are not upgraded. The sha1 is left to be fetched from the repository of the
+// artifact.
+func FixedVersionResolver(fixes map[string]string) NewestVersionResolver {
+	return func(coordinate string) (string, string, error) {
+		xyz := strings.Split(coordinate, ":")
+		if version, ok := fixes[xyz[0]+":"+xyz[1]]; ok {
+			return version, "", nil
+		}
+		return xyz[2], "", nil
+	}
 }
 
-func mavenCentralSha1(x, y, z string) (string, error) {
+// mavenSha1 returns the sha1 of the jar of an artifact in the repository
+func mavenSha1(repository, x, y, z string) (string, error) {
 	// Example: https://repo1.maven.org/maven2/io/opencensus/opencensus-api/0.24.0/opencensus-api-0.24.0.jar.sha1
-	resp, err := http.Get(fmt.Sprintf("https://repo1.maven.org/maven2/%s/%s/%s/%s-%s.jar.sha1", strings.ReplaceAll(x, ".", "/"), y, z, y, z))
+	resp, err := http.Get(fmt.Sprintf("%s/%s/%s/%s/%s-%s.jar.sha1", strings.TrimSuffix(repository, "/"), strings.ReplaceAll(x, ".", "/"), y, z, y, z))
 	if err != nil {
-		return "", fmt.Errorf("failed to fetch sha1 from repo1.maven.org: %w", err)
+		return "", fmt.Errorf("failed to fetch sha1 from %s: %w", repository, err)
 	}
 	defer resp.Body.Close()
 
@@ -91,30 +120,9 @@ func mavenCentralSha1(x, y, z string) (string, error) {
 	return sha1, nil
 }
 
-func Check(e *syntax.CallExpr, namePrefixFilter string, versionFunc NewestVersionResolver) ([]internal.LineReplacement, error) {
-	var mavenJarName string
-	var mavenJarArtifact *parse.MultiPosLiteral
-	var mavenJarSha1 *syntax.Literal
-	// var mavenJarSha256 *syntax.Literal
-
-	for _, arg := range e.Args {
-		if binExp, ok := arg.(*syntax.BinaryExpr); ok && binExp.Op == syntax.EQ {
-			if xIdent, ok := binExp.X.(*syntax.Ident); ok {
-				switch xIdent.Name {
-				case "name":
-					if rhs, ok := binExp.Y.(*syntax.Literal); ok {
-						mavenJarName = rhs.Value.(string)
-					}
-				case "artifact":
-					mavenJarArtifact = parse.ToMultiPosLiteral(binExp.Y)
-				case "sha1":
-					if rhs, ok := binExp.Y.(*syntax.Literal); ok {
-						mavenJarSha1 = rhs
-					}
-				}
-			}
-		}
-	}
+func Check(e *syntax.CallExpr, namePrefixFilter string, versionFunc NewestVersionResolver) ([]internal.Upgrade, error) {
+	attrs := parseAttributes(e)
+	mavenJarName, mavenJarArtifact, mavenJarSha1 := attrs.name, attrs.artifact, attrs.sha1
 
 	// Don't attempt to upgrade this dependency
 	if !strings.HasPrefix(mavenJarName, namePrefixFilter) {
@@ -127,11 +135,14 @@ func Check(e *syntax.CallExpr, namePrefixFilter string, versionFunc NewestVersio
 
 	log.Printf("Checking %s", mavenJarName)
 
-	return findNewerJar(mavenJarArtifact, mavenJarSha1, versionFunc)
+	upgrade := findNewerJar(mavenJarArtifact, mavenJarSha1, attrs.repository, versionFunc)
+	upgrade.Rule = "maven_jar"
+	upgrade.Name = mavenJarName
+	return []internal.Upgrade{upgrade}, nil
 }
 
-func CheckInstall(e *syntax.CallExpr, namePrefixFilter string, versionFunc NewestVersionResolver) ([]internal.LineReplacement, error) {
-	var replacements []internal.LineReplacement
+func CheckInstall(e *syntax.CallExpr, namePrefixFilter string, versionFunc NewestVersionResolver) ([]internal.Upgrade, error) {
+	var upgrades []internal.Upgrade
 	var workspaceName string
 	var artifacts []*parse.MultiPosLiteral
 
@@ -162,30 +173,48 @@ func CheckInstall(e *syntax.CallExpr, namePrefixFilter string, versionFunc Newes
 	log.Printf("Checking %s", workspaceName)
 
 	for _, art := range artifacts {
-		rep, err := findNewerJar(art, nil, versionFunc)
-		if err != nil {
-			log.Println(err)
-			continue
+		// maven_install has no sha1 to update, it's pinned with its own lock file
+		upgrade := findNewerJar(art, nil, "", versionFunc)
+		if upgrade.Err != nil {
+			log.Println(upgrade.Err)
 		}
-		replacements = append(replacements, rep...)
+		upgrade.Rule = "maven_install"
+		upgrade.Name = workspaceName
+		upgrades = append(upgrades, upgrade)
 	}
 
-	return replacements, nil
+	return upgrades, nil
 }
 
-func findNewerJar(dep *parse.MultiPosLiteral, depSha1 *syntax.Literal, versionFunc NewestVersionResolver) ([]internal.LineReplacement, error) {
+// findNewerJar upgrades dep to the version from versionFunc. If depSha1 is set
+// and versionFunc didn't resolve the sha1, it's fetched from the repository.
+func findNewerJar(dep *parse.MultiPosLiteral, depSha1 *syntax.Literal, repository string, versionFunc NewestVersionResolver) internal.Upgrade {
 	var replacements []internal.LineReplacement
 
+	xyz := strings.Split(dep.Value.(string), ":")
+	upgrade := internal.Upgrade{
+		Coordinate: xyz[0] + ":" + xyz[1],
+		OldVersion: xyz[2],
+		NewVersion: xyz[2],
+	}
+
 	newestVersion, sha1, err := versionFunc(dep.Value.(string))
 	if err != nil {
-		return nil, fmt.Errorf("unable to find newer maven_jar: %w", err)
+		upgrade.Err = fmt.Errorf("unable to find newer maven_jar: %w", err)
+		return upgrade
 	}
 
-	xyz := strings.Split(dep.Value.(string), ":")
-
 	// No newer version found
 	if xyz[2] == newestVersion {
-		return nil, nil
+		return upgrade
+	}
+
+	if depSha1 != nil && sha1 == "" {
+		sha1, err = mavenSha1(repository, xyz[0], xyz[1], newestVersion)
+		if err != nil {
+			upgrade.Err = fmt.Errorf("failed to fetch sha1: %w", err)
+			return upgrade
+		}
 	}
 
 	log.Printf("Found: version=%s sha1=%s", newestVersion, sha1)
@@ -208,5 +237,7 @@ func findNewerJar(dep *parse.MultiPosLiteral, depSha1 *syntax.Literal, versionFu
 		})
 	}
 
-	return replacements, nil
+	upgrade.NewVersion = newestVersion
+	upgrade.Replacements = replacements
+	return upgrade
 }
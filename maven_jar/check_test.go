@@ -1,8 +1,12 @@
 package maven_jar
 
 import (
-	"github.com/stretchr/testify/assert"
 	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"go.starlark.net/syntax"
+
+	"github.com/zegl/bazel_dependency_tools/internal"
 )
 
 func TestNewestAvailable(t *testing.T) {
@@ -25,3 +29,43 @@ func TestNewestAvailableOroOro(t *testing.T) {
 	assert.Equal(t, "2.0.8", newest)
 	assert.Equal(t, "5592374f834645c4ae250f4c9fbb314c9369d698", sha1)
 }
+
+func parseCall(t *testing.T, src string) *syntax.CallExpr {
+	f, err := syntax.Parse("WORKSPACE", src, 0)
+	assert.Nil(t, err)
+	return f.Stmts[0].(*syntax.ExprStmt).X.(*syntax.CallExpr)
+}
+
+func TestFixedVersionResolver(t *testing.T) {
+	server := newTestRepository()
+	defer server.Close()
+
+	resolver := FixedVersionResolver(map[string]string{"com.example:unlicensed": "2.0.0"})
+
+	// The sha1 is fetched from the repository of the maven_jar
+	upgrades, err := Check(parseCall(t, `maven_jar(
+    name = "com_example_unlicensed",
+    artifact = "com.example:unlicensed:1.0.0",
+    repository = "`+server.URL+`",
+    sha1 = "b640badcc97f18867c4dfd249ef8d20ec0204c07",
+)`), "", resolver)
+	assert.Nil(t, err)
+	assert.Len(t, upgrades, 1)
+	assert.Nil(t, upgrades[0].Err)
+	assert.Equal(t, []internal.LineReplacement{
+		{Filename: "WORKSPACE", Line: 3, Find: "1.0.0", Substitution: "2.0.0"},
+		{Filename: "WORKSPACE", Line: 5, Find: "b640badcc97f18867c4dfd249ef8d20ec0204c07", Substitution: "0123456789abcdef0123456789abcdef01234567"},
+	}, upgrades[0].Replacements)
+
+	// maven_install has no sha1, so it's not fetched
+	upgrades, err = CheckInstall(parseCall(t, `maven_install(
+    name = "maven",
+    artifacts = ["com.example:other:1.0.0", "com.example:unlicensed:1.0.0"],
+    repositories = ["https://unreachable.example.com"],
+)`), "", FixedVersionResolver(map[string]string{"com.example:other": "2.0.0"}))
+	assert.Nil(t, err)
+	assert.Len(t, upgrades, 2)
+	assert.Nil(t, upgrades[0].Err)
+	assert.Equal(t, "2.0.0", upgrades[0].NewVersion)
+	assert.Equal(t, "1.0.0", upgrades[1].NewVersion)
+}
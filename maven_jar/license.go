@@ -1,67 +1,161 @@
 package maven_jar
 
 import (
-	"encoding/json"
-	"encoding/xml"
 	"errors"
 	"fmt"
 	"io/ioutil"
 	"net/http"
-	"path"
+	"regexp"
 	"strings"
 
 	"go.starlark.net/syntax"
+
+	"github.com/zegl/bazel_dependency_tools/internal/spdx"
 )
 
+// LIC is a license as an SPDX expression, eg. "Apache-2.0"
 type LIC string
 
 const (
 	Apache20Grep LIC = "Apache-2.0"
 )
 
-var ErrSkipped = errors.New("skipped")
+// Where a license was found
+const (
+	LicenseSourcePOM       = "POM"
+	LicenseSourceParentPOM = "parent POM"
+	LicenseSourceGuess     = "guessed from the POM of a newer version"
+)
+
+// PomLicense is the license of an artifact as found in its POM
+type PomLicense struct {
+	SPDX    LIC      // The known licenses as an SPDX expression
+	Unknown []string // The unknown licenses as they are written in the POM
+	Source  string   // Where the license was found, eg. LicenseSourcePOM
+
+	// GuessedFrom is the newer version that the license was guessed from, if
+	// neither the POM of this version nor its parents declare a license
+	GuessedFrom string
+
+	// Declared are all licenses as they are declared in the POM
+	Declared []DeclaredLicense
+}
+
+// DeclaredLicense is a <license> in a POM
+type DeclaredLicense struct {
+	Name         string `xml:"name"`
+	URL          string `xml:"url"`
+	Distribution string `xml:"distribution"`
+	Comments     string `xml:"comments"`
+}
+
+// String returns the license as an SPDX expression. Unknown licenses are
+// referenced with a LicenseRef, eg. "LicenseRef-Example-Corp-License".
+func (l PomLicense) String() string {
+	var alternatives []string
+	if l.SPDX != "" {
+		alternatives = append(alternatives, string(l.SPDX))
+	}
+	for _, name := range l.Unknown {
+		alternatives = append(alternatives, licenseRef(name))
+	}
+	return strings.Join(alternatives, " OR ")
+}
+
+// licenseRefRegex matches the characters that are not allowed in a LicenseRef
+var licenseRefRegex = regexp.MustCompile(`[^A-Za-z0-9.]+`)
+
+// licenseRef returns an SPDX LicenseRef for a license that is not on the SPDX
+// license list
+func licenseRef(name string) string {
+	ref := strings.Trim(licenseRefRegex.ReplaceAllString(name, "-"), "-")
+	if ref == "" {
+		ref = "unknown"
+	}
+	return "LicenseRef-" + ref
+}
 
-func License(e *syntax.CallExpr, namePrefixFilter string) (string, LIC, error) {
-	var mavenJarName string
-	var mavenJarArtifact *syntax.Literal
-	repository := "https://repo1.maven.org/maven2"
-
-	for _, arg := range e.Args {
-		if binExp, ok := arg.(*syntax.BinaryExpr); ok && binExp.Op == syntax.EQ {
-			if xIdent, ok := binExp.X.(*syntax.Ident); ok {
-				switch xIdent.Name {
-				case "name":
-					if rhs, ok := binExp.Y.(*syntax.Literal); ok {
-						mavenJarName = rhs.Value.(string)
-					}
-				case "artifact":
-					if rhs, ok := binExp.Y.(*syntax.Literal); ok {
-						mavenJarArtifact = rhs
-					}
-				case "repository":
-					if rhs, ok := binExp.Y.(*syntax.Literal); ok {
-						repository = rhs.Value.(string)
-					}
-				}
+// Notes returns the names of the unknown licenses, the distribution and
+// comments of the declared licenses, and the version that a guessed license
+// was found in
+func (l PomLicense) Notes() string {
+	var notes []string
+	if len(l.Unknown) > 0 {
+		notes = append(notes, "unknown licenses: "+strings.Join(l.Unknown, ", "))
+	}
+	if l.GuessedFrom != "" {
+		notes = append(notes, fmt.Sprintf("guessed from version %s", l.GuessedFrom))
+	}
+	for _, d := range l.Declared {
+		var parts []string
+		if d.Distribution != "" {
+			parts = append(parts, "distribution: "+strings.TrimSpace(d.Distribution))
+		}
+		if d.Comments != "" {
+			parts = append(parts, "comments: "+strings.Join(strings.Fields(d.Comments), " "))
+		}
+		if len(parts) > 0 {
+			notes = append(notes, fmt.Sprintf("%s (%s)", strings.TrimSpace(d.Name), strings.Join(parts, ", ")))
+		}
+	}
+	return strings.Join(notes, "; ")
+}
+
+// normalizeLicenses converts the licenses in a POM to an SPDX expression. An
+// artifact with multiple licenses can be used under any of them, so they are
+// combined with OR. Licenses that are unknown are kept as they are written.
+func normalizeLicenses(declared []DeclaredLicense) PomLicense {
+	res := PomLicense{Declared: declared}
+
+	var known []string
+	seen := make(map[string]bool)
+	for _, d := range declared {
+		if expr, ok := spdx.Normalize(d.Name, d.URL); ok {
+			if strings.Contains(expr, " AND ") {
+				expr = "(" + expr + ")"
+			}
+			if !seen[expr] {
+				seen[expr] = true
+				known = append(known, expr)
 			}
+			continue
 		}
+
+		name := strings.TrimSpace(d.Name)
+		if name == "" {
+			name = strings.TrimSpace(d.URL)
+		}
+		res.Unknown = append(res.Unknown, name)
 	}
 
+	res.SPDX = LIC(strings.Join(known, " OR "))
+	return res
+}
+
+var ErrSkipped = errors.New("skipped")
+
+// License returns the name of a maven_jar, its coordinate as
+// "group:artifact:version", and the license of the artifact
+func License(e *syntax.CallExpr, namePrefixFilter string) (name, coordinate string, license PomLicense, err error) {
+	attrs := parseAttributes(e)
+	mavenJarName, mavenJarArtifact, repository := attrs.name, attrs.artifact, attrs.repository
+
 	// Don't check this dependency
 	if !strings.HasPrefix(mavenJarName, namePrefixFilter) {
-		return "", "", ErrSkipped
+		return "", "", PomLicense{}, ErrSkipped
 	}
 
 	if mavenJarArtifact == nil {
-		return mavenJarName, "", fmt.Errorf("unable to parse %s", mavenJarName)
+		return mavenJarName, "", PomLicense{}, fmt.Errorf("unable to parse %s", mavenJarName)
 	}
 
 	x, y, z := strToCoord(mavenJarArtifact.Value.(string))
-	license, err := mavenLicense(repository, x, y, z)
+	coordinate = x + ":" + y + ":" + z
+	license, err = mavenLicense(repository, x, y, z)
 	if err != nil {
-		return mavenJarName, "", err
+		return mavenJarName, coordinate, PomLicense{}, err
 	}
-	return mavenJarName, license, nil
+	return mavenJarName, coordinate, license, nil
 }
 
 func strToCoord(s string) (string, string, string) {
@@ -69,156 +163,116 @@ func strToCoord(s string) (string, string, string) {
 	return xyz[0], xyz[1], xyz[len(xyz)-1]
 }
 
+// ArtifactLicense is the license of an artifact of a maven_install, Err is
+// set if the license couldn't be found
 type ArtifactLicense struct {
 	Art     string
-	License LIC
+	License PomLicense
+	Err     error
 }
 
+// LicenseMavenInstall finds the licenses of all artifacts in the
+// maven_install.json of a maven_install. The POM of each artifact is fetched
+// from the repository it was pinned from, or from its mirrors. An error is only
+// returned if the maven_install.json can't be read, errors of single artifacts
+// are returned in their ArtifactLicense.
 func LicenseMavenInstall(e *syntax.CallExpr, namePrefixFilter, workspacePath string) ([]ArtifactLicense, error) {
-	var mavenInstallName string
-	var pinningJson string
-	// var mavenJarArtifact *syntax.Literal
-	// repository := "https://repo1.maven.org/maven2"
-	for _, arg := range e.Args {
-		if binExp, ok := arg.(*syntax.BinaryExpr); ok && binExp.Op == syntax.EQ {
-			if xIdent, ok := binExp.X.(*syntax.Ident); ok {
-				switch xIdent.Name {
-				case "name":
-					if rhs, ok := binExp.Y.(*syntax.Literal); ok {
-						mavenInstallName = rhs.Value.(string)
-					}
-				case "maven_install_json":
-					if rhs, ok := binExp.Y.(*syntax.Literal); ok {
-						pinningJson = rhs.Value.(string)
-					}
-				}
-			}
-		}
-	}
-
-	// Don't check this dependency
-	if !strings.HasPrefix(mavenInstallName, namePrefixFilter) {
-		return nil, ErrSkipped
-	}
-
-	p := path.Join(path.Dir(workspacePath), strings.ReplaceAll(pinningJson, ":", "/"))
-
-	pinningJsonData, err := ioutil.ReadFile(p)
-	if err != nil {
-		return nil, err
-	}
-
-	type pinningSchema struct {
-		DependencyTree struct {
-			Dependencies []struct {
-				Coord              string        `json:"coord"`
-				Dependencies       []interface{} `json:"dependencies"`
-				DirectDependencies []interface{} `json:"directDependencies"`
-				File               string        `json:"file"`
-				MirrorUrls         []string      `json:"mirror_urls"`
-				Sha256             string        `json:"sha256"`
-				URL                string        `json:"url"`
-			} `json:"dependencies"`
-			Version string `json:"version"`
-		} `json:"dependency_tree"`
-	}
-
-	var pinning pinningSchema
-	err = json.Unmarshal(pinningJsonData, &pinning)
+	install, err := readPinnedArtifacts(e, namePrefixFilter, workspacePath)
 	if err != nil {
 		return nil, err
 	}
+	return installLicenses(install), nil
+}
 
+func installLicenses(install *pinnedInstall) []ArtifactLicense {
 	var res []ArtifactLicense
 
-	for _, dep := range pinning.DependencyTree.Dependencies {
+	for _, dep := range install.Artifacts {
 		x, y, z := strToCoord(dep.Coord)
-		license, err := mavenLicense("https://repo1.maven.org/maven2", x, y, z)
-		if err != nil {
-			return nil, err
+
+		var license PomLicense
+		var errs []string
+		for _, repository := range dep.repositories(install.Repositories) {
+			l, err := mavenLicense(repository, x, y, z)
+			if err == nil {
+				license, errs = l, nil
+				break
+			}
+			errs = append(errs, fmt.Sprintf("%s: %s", repository, err))
 		}
+
 		res = append(res, ArtifactLicense{
 			Art:     dep.Coord,
 			License: license,
 		})
+		if len(errs) > 0 {
+			res[len(res)-1].Err = errors.New(strings.Join(errs, "; "))
+		}
 	}
 
-	return res, nil
+	return res
 }
 
-func mavenLicense(repository, x, y, z string) (LIC, error) {
-	pom, err := fetchPom(repository, x, y, z)
-	if err != nil {
-		return "", err
+// mavenLicense finds the license of an artifact in its POM, or in its parent
+// POMs. If none of them declare a license, the license is guessed from the POM
+// of the newest version of the artifact in the same repository.
+func mavenLicense(repository, x, y, z string) (PomLicense, error) {
+	license, err := pomLicense(repository, x, y, z)
+	if err != errNoLicense {
+		return license, err
 	}
 
-	if strings.Contains(pom, "http://www.apache.org/licenses/LICENSE-2.0") {
-		return "Apache License, Version 2.0", nil
-	}
-	if strings.Contains(pom, "Apache License, Version 2.0") {
-		return "Apache License, Version 2.0", nil
-	}
-	if strings.Contains(pom, "Eclipse Public License v1.0") {
-		return "Eclipse Public License v1.0", nil
-	}
-	if strings.Contains(pom, "GNU Lesser General Public License version 2.1") {
-		return "GNU Lesser General Public License version 2.1", nil
+	// Newer versions sometimes add licenses that were missing, but there's no
+	// guarantee that it's the same license as in this version
+	newZ, newErr := newestVersion(repository, x, y)
+	if newZ != z && newErr == nil {
+		if l, err := pomLicense(repository, x, y, newZ); err == nil {
+			l.Source = LicenseSourceGuess
+			l.GuessedFrom = newZ
+			return l, nil
+		}
 	}
 
-	type pomxml struct {
-		Licenses []struct {
-			License struct {
-				Name string `xml:"name"`
-			} `xml:"license"`
-		} `xml:"licenses"`
-
-		Parent struct {
-			GroupID    string `xml:"groupId"`
-			ArtifactID string `xml:"artifactId"`
-			Version    string `xml:"version"`
-		} `xml:"parent"`
-	}
+	return PomLicense{}, err
+}
 
-	var l pomxml
-	err = xml.Unmarshal([]byte(pom), &l)
+// pomLicense finds the license of an artifact in its POM, or in its parent POMs
+func pomLicense(repository, x, y, z string) (PomLicense, error) {
+	effective, err := resolvePom(repository, x, y, z)
 	if err != nil {
-		return "", fmt.Errorf("unmarshal maven XML failed: %w", err)
+		return PomLicense{}, err
 	}
 
-	if len(l.Licenses) == 1 {
-		return LIC(l.Licenses[0].License.Name), nil
-	}
-
-	// If has parent, check there
-	if l.Parent.ArtifactID != "" {
-		return mavenLicense(repository, l.Parent.GroupID, l.Parent.ArtifactID, l.Parent.Version)
+	license, i, err := effective.license()
+	if err != nil {
+		return PomLicense{}, err
 	}
 
-	// Check newer version
-	newZ, _, err := NewestAvailable(fmt.Sprintf("%s:%s:%s", x, y, z))
-	if newZ != z && err == nil {
-		if l, err := mavenLicense(repository, x, y, newZ); err == nil {
-			return l, nil
-		}
+	license.Source = LicenseSourcePOM
+	if i > 0 {
+		license.Source = LicenseSourceParentPOM
 	}
-
-	return "", errors.New("no license found")
+	return license, nil
 }
 
 func fetchPom(repository, x, y, z string) (string, error) {
 	// https://repo1.maven.org/maven2/net/sourceforge/argparse4j/argparse4j/0.4.3/argparse4j-0.4.3.pom
 	// https://repo1.maven.org/maven2/software/amazon/awssdk/aws-query-protocol/2.7.5/aws-query-protocol-2.7.5.pom
 	// https://repo1.maven.org/maven2/software/amazon/awssdk/aws-xml-protocol/jar/aws-xml-protocol-jar.pom
-	url := fmt.Sprintf("%s/%s/%s/%s/%s-%s.pom", repository, strings.ReplaceAll(x, ".", "/"), y, z, y, z)
+	url := fmt.Sprintf("%s/%s/%s/%s/%s-%s.pom", strings.TrimSuffix(repository, "/"), strings.ReplaceAll(x, ".", "/"), y, z, y, z)
 	resp, err := http.Get(url)
 	if err != nil {
-		return "", fmt.Errorf("failed to fetch sha1 from repo1.maven.org: %w", err)
+		return "", fmt.Errorf("failed to fetch %s: %w", url, err)
 	}
 	defer resp.Body.Close()
 
+	if resp.StatusCode != http.StatusOK {
+		return "", fmt.Errorf("failed to fetch %s: %s", url, resp.Status)
+	}
+
 	allData, err := ioutil.ReadAll(resp.Body)
 	if err != nil {
-		return "", fmt.Errorf("failed to read sha1: %w", err)
+		return "", fmt.Errorf("failed to read %s: %w", url, err)
 	}
 
 	return string(allData), nil
@@ -0,0 +1,132 @@
+package maven_jar
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+
+	"github.com/zegl/bazel_dependency_tools/internal/spdx"
+)
+
+// newTestRepository serves POMs from testdata, as a Maven repository
+func newTestRepository() *httptest.Server {
+	return httptest.NewServer(http.FileServer(http.Dir("testdata/repository")))
+}
+
+func TestMavenLicenseNormalized(t *testing.T) {
+	server := newTestRepository()
+	defer server.Close()
+
+	license, err := mavenLicense(server.URL, "com.example", "mit", "1.0")
+	assert.Nil(t, err)
+	assert.Equal(t, LIC("MIT"), license.SPDX)
+	assert.Equal(t, "MIT", license.String())
+	assert.Equal(t, LicenseSourcePOM, license.Source)
+
+	license, err = mavenLicense(server.URL, "com.example", "custom", "1.0")
+	assert.Nil(t, err)
+	assert.Equal(t, LIC(""), license.SPDX)
+	assert.Equal(t, []string{"Example Corp License"}, license.Unknown)
+	assert.Equal(t, "LicenseRef-Example-Corp-License", license.String())
+	assert.Equal(t, "unknown licenses: Example Corp License", license.Notes())
+}
+
+func TestMavenLicenseParent(t *testing.T) {
+	server := newTestRepository()
+	defer server.Close()
+
+	license, err := mavenLicense(server.URL, "com.example", "child", "1.0")
+	assert.Nil(t, err)
+	assert.Equal(t, "MIT", license.String())
+	assert.Equal(t, LicenseSourceParentPOM, license.Source)
+}
+
+func TestMavenLicenseGuessed(t *testing.T) {
+	server := newTestRepository()
+	defer server.Close()
+
+	// The newest version is found in the same repository
+	license, err := mavenLicense(server.URL, "com.example", "unlicensed", "1.0.0")
+	assert.Nil(t, err)
+	assert.Equal(t, "MIT", license.String())
+	assert.Equal(t, LicenseSourceGuess, license.Source)
+	assert.Equal(t, "2.0.0", license.GuessedFrom)
+
+	_, err = mavenLicense(server.URL, "com.example", "unlicensed", "2.0.0")
+	assert.Nil(t, err)
+}
+
+func TestMavenLicenseMultiple(t *testing.T) {
+	server := newTestRepository()
+	defer server.Close()
+
+	license, err := mavenLicense(server.URL, "com.example", "dual", "1.0")
+	assert.Nil(t, err)
+	assert.Equal(t, "EPL-1.0 OR LGPL-2.1-only", license.String())
+	assert.Len(t, license.Declared, 2)
+	assert.Equal(t, "Eclipse Public License - v 1.0 (distribution: repo); GNU Lesser General Public License (comments: Can be used under the terms of either license)", license.Notes())
+}
+
+func TestNormalizeLicenses(t *testing.T) {
+	license := normalizeLicenses([]DeclaredLicense{
+		{Name: "Apache License, Version 2.0"},
+		{Name: "The Apache Software License, Version 2.0"},
+		{Name: "Example Corp License, Version 1 | 2"},
+	})
+	assert.Equal(t, "Apache-2.0 OR LicenseRef-Example-Corp-License-Version-1-2", license.String())
+
+	_, err := spdx.Parse(license.String())
+	assert.Nil(t, err)
+}
+
+func TestPinnedArtifactRepositories(t *testing.T) {
+	artifact := pinnedArtifact{
+		Coord: "com.google.guava:guava:jar:sources:28.1-jre",
+		URL:   "https://maven.example.com/releases/com/google/guava/guava/28.1-jre/guava-28.1-jre-sources.jar",
+		MirrorURLs: []string{
+			"https://maven.example.com/releases/com/google/guava/guava/28.1-jre/guava-28.1-jre-sources.jar",
+			"https://repo1.maven.org/maven2/com/google/guava/guava/28.1-jre/guava-28.1-jre-sources.jar",
+		},
+	}
+
+	assert.Equal(t, []string{
+		"https://maven.example.com/releases",
+		"https://repo1.maven.org/maven2",
+		"https://jcenter.bintray.com",
+	}, artifact.repositories([]string{"https://repo1.maven.org/maven2/", "https://jcenter.bintray.com"}))
+}
+
+func TestInstallLicenses(t *testing.T) {
+	server := newTestRepository()
+	defer server.Close()
+
+	// A repository without any artifacts
+	empty := httptest.NewServer(http.NotFoundHandler())
+	defer empty.Close()
+
+	licenses := installLicenses(&pinnedInstall{
+		Name:         "maven",
+		Repositories: []string{server.URL},
+		Artifacts: []pinnedArtifact{
+			{Coord: "com.example:mit:1.0", URL: empty.URL + "/com/example/mit/1.0/mit-1.0.jar"},
+			{Coord: "com.example:missing:1.0", URL: empty.URL + "/com/example/missing/1.0/missing-1.0.jar"},
+			{Coord: "com.example:dual:1.0", URL: server.URL + "/com/example/dual/1.0/dual-1.0.jar"},
+		},
+	})
+
+	assert.Len(t, licenses, 3)
+
+	// Found in the repositories of maven_install
+	assert.Equal(t, "MIT", licenses[0].License.String())
+	assert.Nil(t, licenses[0].Err)
+
+	// The other artifacts are checked even if one of them fails
+	assert.Equal(t, "com.example:missing:1.0", licenses[1].Art)
+	assert.EqualError(t, licenses[1].Err, empty.URL+": failed to fetch "+empty.URL+"/com/example/missing/1.0/missing-1.0.pom: 404 Not Found; "+
+		server.URL+": failed to fetch "+server.URL+"/com/example/missing/1.0/missing-1.0.pom: 404 Not Found")
+
+	assert.Equal(t, "EPL-1.0 OR LGPL-2.1-only", licenses[2].License.String())
+	assert.Nil(t, licenses[2].Err)
+}
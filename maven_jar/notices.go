@@ -0,0 +1,23 @@
+package maven_jar
+
+import (
+	"regexp"
+
+	"github.com/zegl/bazel_dependency_tools/internal/archive"
+	"github.com/zegl/bazel_dependency_tools/internal/download"
+)
+
+// Matches license and NOTICE files in the root or in META-INF of a jar, eg.
+// "META-INF/LICENSE.txt"
+var jarLicenseFileRegex = regexp.MustCompile(`(?i)^(META-INF/)?(licen[cs]e|notice)([-._][^/]*)?$`)
+
+// LicenseFiles downloads a jar, and returns the license and NOTICE files in it
+// by their path. sha256sum is used to find the jar in the repository cache,
+// and may be empty.
+func LicenseFiles(downloader *download.Downloader, url, sha256sum string) (map[string][]byte, error) {
+	data, err := downloader.GetSha256(url, sha256sum)
+	if err != nil {
+		return nil, err
+	}
+	return archive.ReadFiles(url, data, jarLicenseFileRegex.MatchString)
+}
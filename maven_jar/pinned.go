@@ -0,0 +1,135 @@
+package maven_jar
+
+import (
+	"encoding/json"
+	"errors"
+	"io/ioutil"
+	"path"
+	"strings"
+
+	"go.starlark.net/syntax"
+)
+
+// pinnedArtifact is an artifact in the maven_install.json of a maven_install
+type pinnedArtifact struct {
+	Coord      string   `json:"coord"`
+	File       string   `json:"file"`
+	MirrorURLs []string `json:"mirror_urls"`
+	Sha256     string   `json:"sha256"`
+	URL        string   `json:"url"`
+}
+
+// repositories returns the repositories that the artifact was pinned from, as
+// found in its url and mirror_urls, followed by the other repositories
+func (a pinnedArtifact) repositories(other []string) []string {
+	var res []string
+	seen := make(map[string]bool)
+	add := func(repository string) {
+		repository = strings.TrimSuffix(repository, "/")
+		if repository != "" && !seen[repository] {
+			seen[repository] = true
+			res = append(res, repository)
+		}
+	}
+
+	if art, err := parseArtifact(a.Coord); err == nil {
+		// The path of the artifact in the repository, eg. "/junit/junit/4.12/"
+		artifactPath := "/" + strings.ReplaceAll(art.Group, ".", "/") + "/" + art.ID + "/" + art.Version + "/"
+		for _, url := range append([]string{a.URL}, a.MirrorURLs...) {
+			if i := strings.LastIndex(url, artifactPath); i > 0 {
+				add(url[:i])
+			}
+		}
+	}
+
+	for _, repository := range other {
+		add(repository)
+	}
+
+	return res
+}
+
+// pinnedInstall is a maven_install, and the artifacts in its maven_install.json
+type pinnedInstall struct {
+	Name         string
+	Repositories []string
+	Artifacts    []pinnedArtifact // All artifacts, including the transitive dependencies
+}
+
+// readPinnedArtifacts reads the maven_install.json of a maven_install
+func readPinnedArtifacts(e *syntax.CallExpr, namePrefixFilter, workspacePath string) (*pinnedInstall, error) {
+	var mavenInstallName string
+	var pinningJson string
+	var repositories []string
+	for _, arg := range e.Args {
+		if binExp, ok := arg.(*syntax.BinaryExpr); ok && binExp.Op == syntax.EQ {
+			if xIdent, ok := binExp.X.(*syntax.Ident); ok {
+				switch xIdent.Name {
+				case "name":
+					if rhs, ok := binExp.Y.(*syntax.Literal); ok {
+						mavenInstallName = rhs.Value.(string)
+					}
+				case "maven_install_json":
+					if rhs, ok := binExp.Y.(*syntax.Literal); ok {
+						pinningJson = rhs.Value.(string)
+					}
+				case "repositories":
+					if list, ok := binExp.Y.(*syntax.ListExpr); ok {
+						for _, v := range list.List {
+							if lit, ok := v.(*syntax.Literal); ok {
+								repositories = append(repositories, lit.Value.(string))
+							}
+						}
+					}
+				}
+			}
+		}
+	}
+
+	// Don't check this dependency
+	if !strings.HasPrefix(mavenInstallName, namePrefixFilter) {
+		return nil, ErrSkipped
+	}
+
+	if pinningJson == "" {
+		return nil, errors.New("maven_install is not pinned, maven_install_json is not set")
+	}
+
+	// The default of maven_install
+	if len(repositories) == 0 {
+		repositories = []string{centralRepository}
+	}
+
+	pinningJsonData, err := ioutil.ReadFile(path.Join(path.Dir(workspacePath), labelPath(pinningJson)))
+	if err != nil {
+		return nil, err
+	}
+
+	type pinningSchema struct {
+		DependencyTree struct {
+			Dependencies []pinnedArtifact `json:"dependencies"`
+			Version      string           `json:"version"`
+		} `json:"dependency_tree"`
+	}
+
+	var pinning pinningSchema
+	err = json.Unmarshal(pinningJsonData, &pinning)
+	if err != nil {
+		return nil, err
+	}
+
+	return &pinnedInstall{
+		Name:         mavenInstallName,
+		Repositories: repositories,
+		Artifacts:    pinning.DependencyTree.Dependencies,
+	}, nil
+}
+
+// labelPath returns the path of a label in the main repository relative to
+// the workspace root, eg. "//third_party:maven_install.json" is
+// "third_party/maven_install.json"
+func labelPath(label string) string {
+	label = strings.TrimPrefix(label, "@")
+	label = strings.TrimPrefix(label, "//")
+	return strings.Replace(label, ":", "/", 1)
+}
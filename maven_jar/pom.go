@@ -0,0 +1,203 @@
+package maven_jar
+
+import (
+	"encoding/xml"
+	"errors"
+	"fmt"
+	"regexp"
+	"strings"
+)
+
+// The maximum number of parents of a POM, to stop on cycles
+const maxParents = 10
+
+var errNoLicense = errors.New("no license found")
+
+// pom is the part of a Maven POM that is needed to find its license
+type pom struct {
+	GroupID    string `xml:"groupId"`
+	ArtifactID string `xml:"artifactId"`
+	Version    string `xml:"version"`
+
+	Parent struct {
+		GroupID    string `xml:"groupId"`
+		ArtifactID string `xml:"artifactId"`
+		Version    string `xml:"version"`
+
+		// RelativePath is where the parent is found in the source tree. It is
+		// only used when building from source, POMs from a repository always
+		// have their parent resolved by its coordinates.
+		RelativePath string `xml:"relativePath"`
+	} `xml:"parent"`
+
+	Properties properties        `xml:"properties"`
+	Licenses   []DeclaredLicense `xml:"licenses>license"`
+}
+
+// properties are the <properties> of a POM, by their name
+type properties map[string]string
+
+func (p *properties) UnmarshalXML(d *xml.Decoder, start xml.StartElement) error {
+	*p = make(properties)
+	for {
+		tok, err := d.Token()
+		if err != nil {
+			return err
+		}
+		switch t := tok.(type) {
+		case xml.StartElement:
+			var value string
+			if err := d.DecodeElement(&value, &t); err != nil {
+				return err
+			}
+			(*p)[t.Name.Local] = strings.TrimSpace(value)
+		case xml.EndElement:
+			return nil
+		}
+	}
+}
+
+// effectivePom is a POM together with its parents, the closest parent first
+type effectivePom struct {
+	chain      []*pom
+	properties map[string]string
+}
+
+// resolvePom fetches the POM of an artifact and all of its parents, and
+// resolves the properties that are available for interpolation. Parents that
+// fail to be fetched are an error, unless a POM closer to the artifact already
+// declares licenses.
+func resolvePom(repository, groupID, artifactID, version string) (*effectivePom, error) {
+	p, err := fetchPomModel(repository, groupID, artifactID, version)
+	if err != nil {
+		return nil, err
+	}
+
+	res := &effectivePom{chain: []*pom{p}}
+	seen := map[string]bool{groupID + ":" + artifactID + ":" + version: true}
+	hasLicenses := len(p.Licenses) > 0
+
+	for p.Parent.ArtifactID != "" {
+		// The coordinates of the parent can use the properties of the child, eg.
+		// ${project.version} or ${revision}
+		props := chainProperties([]*pom{p})
+		g := interpolate(p.Parent.GroupID, props)
+		a := interpolate(p.Parent.ArtifactID, props)
+		v := interpolate(p.Parent.Version, props)
+		coordinate := g + ":" + a + ":" + v
+
+		if strings.Contains(coordinate, "${") {
+			err = fmt.Errorf("unresolved property in parent %s", coordinate)
+		} else if seen[coordinate] || len(res.chain) > maxParents {
+			err = fmt.Errorf("parent %s is part of a cycle", coordinate)
+		} else {
+			seen[coordinate] = true
+			p, err = fetchPomModel(repository, g, a, v)
+		}
+		if err != nil {
+			if hasLicenses {
+				break
+			}
+			return nil, fmt.Errorf("failed to resolve the parent of %s:%s:%s: %w", groupID, artifactID, version, err)
+		}
+
+		res.chain = append(res.chain, p)
+		hasLicenses = hasLicenses || len(p.Licenses) > 0
+	}
+
+	res.properties = chainProperties(res.chain)
+	return res, nil
+}
+
+// chainProperties returns the properties of a POM and its parents, where the
+// properties of a POM override those of its parents
+func chainProperties(chain []*pom) map[string]string {
+	props := make(map[string]string)
+	for i := len(chain) - 1; i >= 0; i-- {
+		for k, v := range chain[i].Properties {
+			props[k] = v
+		}
+	}
+
+	// The built-in project properties refer to the first POM. The group and
+	// the version are inherited from the parent if they're not set.
+	project := chain[0]
+	groupID, version := project.GroupID, project.Version
+	if groupID == "" {
+		groupID = project.Parent.GroupID
+	}
+	if version == "" {
+		version = project.Parent.Version
+	}
+
+	for _, prefix := range []string{"project.", "pom.", ""} {
+		props[prefix+"groupId"] = groupID
+		props[prefix+"artifactId"] = project.ArtifactID
+		props[prefix+"version"] = version
+		props[prefix+"parent.groupId"] = project.Parent.GroupID
+		props[prefix+"parent.artifactId"] = project.Parent.ArtifactID
+		props[prefix+"parent.version"] = project.Parent.Version
+	}
+
+	resolved := make(map[string]string, len(props))
+	for k, v := range props {
+		resolved[k] = interpolate(v, props)
+	}
+	return resolved
+}
+
+// license returns the licenses of the closest POM that declares any, and the
+// position of that POM in the chain. Licenses are inherited from the parents.
+func (e *effectivePom) license() (PomLicense, int, error) {
+	for i, p := range e.chain {
+		if len(p.Licenses) == 0 {
+			continue
+		}
+
+		declared := make([]DeclaredLicense, len(p.Licenses))
+		for j, l := range p.Licenses {
+			declared[j] = DeclaredLicense{
+				Name:         interpolate(l.Name, e.properties),
+				URL:          interpolate(l.URL, e.properties),
+				Distribution: interpolate(l.Distribution, e.properties),
+				Comments:     interpolate(l.Comments, e.properties),
+			}
+		}
+		return normalizeLicenses(declared), i, nil
+	}
+
+	return PomLicense{}, 0, errNoLicense
+}
+
+var propertyRegex = regexp.MustCompile(`\$\{([^}]+)\}`)
+
+// interpolate replaces ${name} with the value of the property. Properties can
+// refer to other properties, unknown properties are kept as they are.
+func interpolate(s string, props map[string]string) string {
+	for i := 0; i < maxParents && strings.Contains(s, "${"); i++ {
+		next := propertyRegex.ReplaceAllStringFunc(s, func(m string) string {
+			if v, ok := props[m[2:len(m)-1]]; ok {
+				return v
+			}
+			return m
+		})
+		if next == s {
+			break
+		}
+		s = next
+	}
+	return s
+}
+
+func fetchPomModel(repository, groupID, artifactID, version string) (*pom, error) {
+	data, err := fetchPom(repository, groupID, artifactID, version)
+	if err != nil {
+		return nil, err
+	}
+
+	var p pom
+	if err := xml.Unmarshal([]byte(data), &p); err != nil {
+		return nil, fmt.Errorf("unmarshal maven XML failed: %w", err)
+	}
+	return &p, nil
+}
@@ -0,0 +1,48 @@
+package maven_jar
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestInterpolate(t *testing.T) {
+	props := map[string]string{
+		"project.version": "1.2.3",
+		"revision":        "${project.version}",
+		"self":            "${self}",
+	}
+	assert.Equal(t, "v1.2.3", interpolate("v${revision}", props))
+	assert.Equal(t, "1.2.3-${unknown}", interpolate("${project.version}-${unknown}", props))
+	assert.Equal(t, "${self}", interpolate("${self}", props))
+}
+
+func TestResolvePomParentChain(t *testing.T) {
+	server := newTestRepository()
+	defer server.Close()
+
+	effective, err := resolvePom(server.URL, "com.example", "interpolated", "2.0")
+	assert.Nil(t, err)
+	assert.Len(t, effective.chain, 3)
+	assert.Equal(t, "com.example", effective.properties["project.groupId"])
+	assert.Equal(t, "2.0", effective.properties["project.version"])
+
+	// The licenses of the grandparent use the properties of the artifact
+	license, i, err := effective.license()
+	assert.Nil(t, err)
+	assert.Equal(t, 2, i)
+	assert.Equal(t, "MIT", license.String())
+	assert.Equal(t, "https://example.com/interpolated/LICENSE", license.Declared[0].URL)
+
+	license, err = mavenLicense(server.URL, "com.example", "interpolated", "2.0")
+	assert.Nil(t, err)
+	assert.Equal(t, LicenseSourceParentPOM, license.Source)
+}
+
+func TestResolvePomCycle(t *testing.T) {
+	server := newTestRepository()
+	defer server.Close()
+
+	_, err := resolvePom(server.URL, "com.example", "cycle", "1.0")
+	assert.EqualError(t, err, "failed to resolve the parent of com.example:cycle:1.0: parent com.example:cycle:1.0 is part of a cycle")
+}
@@ -0,0 +1,94 @@
+package maven_jar
+
+import (
+	"fmt"
+	"strings"
+
+	"go.starlark.net/syntax"
+
+	"github.com/zegl/bazel_dependency_tools/internal/sbom"
+)
+
+// Package describes a maven_jar for an SBOM. The license is not set.
+func Package(e *syntax.CallExpr, namePrefixFilter string) (*sbom.Package, error) {
+	attrs := parseAttributes(e)
+
+	// Don't check this dependency
+	if !strings.HasPrefix(attrs.name, namePrefixFilter) {
+		return nil, ErrSkipped
+	}
+
+	if attrs.artifact == nil {
+		return nil, fmt.Errorf("unable to parse %s", attrs.name)
+	}
+
+	p, err := artifactPackage(attrs.artifact.Value.(string), attrs.repository)
+	if err != nil {
+		return nil, err
+	}
+	p.Rule = "maven_jar"
+	p.Name = attrs.name
+	if attrs.sha1 != nil {
+		p.Hashes = append(p.Hashes, sbom.Hash{Algorithm: sbom.SHA1, Value: attrs.sha1.Value.(string)})
+	}
+	if attrs.sha256 != nil {
+		p.Hashes = append(p.Hashes, sbom.Hash{Algorithm: sbom.SHA256, Value: attrs.sha256.Value.(string)})
+	}
+	return p, nil
+}
+
+// PackagesMavenInstall describes all artifacts in the maven_install.json of a
+// maven_install for an SBOM, including the transitive dependencies. The
+// licenses are not set.
+func PackagesMavenInstall(e *syntax.CallExpr, namePrefixFilter, workspacePath string) ([]sbom.Package, error) {
+	install, err := readPinnedArtifacts(e, namePrefixFilter, workspacePath)
+	if err != nil {
+		return nil, err
+	}
+
+	var res []sbom.Package
+	for _, dep := range install.Artifacts {
+		p, err := artifactPackage(dep.Coord, "")
+		if err != nil {
+			return nil, err
+		}
+		p.Rule = "maven_install"
+		p.Name = dep.Coord
+		p.URL = dep.URL
+		if dep.Sha256 != "" {
+			p.Hashes = []sbom.Hash{{Algorithm: sbom.SHA256, Value: dep.Sha256}}
+		}
+		res = append(res, *p)
+	}
+
+	return res, nil
+}
+
+// artifactPackage creates a package with the version and the purl of an
+// artifact. The URL is set if the repository is not empty.
+func artifactPackage(coordinate, repository string) (*sbom.Package, error) {
+	a, err := parseArtifact(coordinate)
+	if err != nil {
+		return nil, err
+	}
+
+	qualifiers := map[string]string{"classifier": a.Classifier}
+	if a.Packaging != "jar" {
+		qualifiers["type"] = a.Packaging
+	}
+
+	p := &sbom.Package{
+		Group:    a.Group,
+		Artifact: a.ID,
+		Version:  a.Version,
+		PURL:     sbom.PURL("maven", a.Group+"/"+a.ID, a.Version, qualifiers),
+	}
+
+	if repository != "" {
+		if p.URL, err = jarURL(repository, coordinate); err != nil {
+			return nil, err
+		}
+	}
+
+	return p, nil
+}
@@ -0,0 +1,102 @@
+package maven_jar
+
+import (
+	"errors"
+	"fmt"
+	"log"
+	"strings"
+
+	"go.starlark.net/syntax"
+
+	"github.com/zegl/bazel_dependency_tools/internal"
+	"github.com/zegl/bazel_dependency_tools/internal/download"
+)
+
+// Verify downloads the jar of a maven_jar and checks that it matches the
+// declared sha256 or sha1
+func Verify(e *syntax.CallExpr, namePrefixFilter string, downloader *download.Downloader) (*internal.Verification, error) {
+	attrs := parseAttributes(e)
+	mavenJarName, mavenJarArtifact, mavenJarSha1, mavenJarSha256, repository := attrs.name, attrs.artifact, attrs.sha1, attrs.sha256, attrs.repository
+
+	// Don't verify this dependency
+	if !strings.HasPrefix(mavenJarName, namePrefixFilter) {
+		return nil, nil
+	}
+
+	if mavenJarArtifact == nil {
+		return nil, fmt.Errorf("unable to parse %s", mavenJarName)
+	}
+
+	log.Printf("Verifying %s", mavenJarName)
+
+	start, _ := e.Span()
+	res := &internal.Verification{
+		Rule:     "maven_jar",
+		Name:     mavenJarName,
+		Filename: start.Filename(),
+		Line:     start.Line,
+	}
+
+	url, err := jarURL(repository, mavenJarArtifact.Value.(string))
+	if err != nil {
+		res.Err = err
+		return res, nil
+	}
+
+	var expected, actual string
+	switch {
+	case mavenJarSha256 != nil:
+		expected = mavenJarSha256.Value.(string)
+		actual, err = downloader.Sha256(url)
+	case mavenJarSha1 != nil:
+		expected = mavenJarSha1.Value.(string)
+		actual, err = downloader.Sha1(url)
+	default:
+		res.Err = internal.ErrNoChecksum
+		return res, nil
+	}
+
+	if err != nil {
+		res.Err = err
+	} else if actual != expected {
+		res.Err = fmt.Errorf("checksum mismatch for %s: expected %s, got %s", url, expected, actual)
+	}
+
+	return res, nil
+}
+
+// artifact is a Maven coordinate, in the form "group:artifact:version",
+// "group:artifact:packaging:version" or "group:artifact:packaging:classifier:version"
+type artifact struct {
+	Group, ID, Packaging, Classifier, Version string
+}
+
+func parseArtifact(coordinate string) (artifact, error) {
+	parts := strings.Split(coordinate, ":")
+
+	switch len(parts) {
+	case 3:
+		return artifact{Group: parts[0], ID: parts[1], Packaging: "jar", Version: parts[2]}, nil
+	case 4:
+		return artifact{Group: parts[0], ID: parts[1], Packaging: parts[2], Version: parts[3]}, nil
+	case 5:
+		return artifact{Group: parts[0], ID: parts[1], Packaging: parts[2], Classifier: parts[3], Version: parts[4]}, nil
+	default:
+		return artifact{}, errors.New("unexpected artifact format: " + coordinate)
+	}
+}
+
+// jarURL returns the URL of the jar of an artifact
+func jarURL(repository, coordinate string) (string, error) {
+	a, err := parseArtifact(coordinate)
+	if err != nil {
+		return "", err
+	}
+
+	file := a.ID + "-" + a.Version
+	if a.Classifier != "" {
+		file += "-" + a.Classifier
+	}
+
+	return fmt.Sprintf("%s/%s/%s/%s/%s.%s", strings.TrimSuffix(repository, "/"), strings.ReplaceAll(a.Group, ".", "/"), a.ID, a.Version, file, a.Packaging), nil
+}
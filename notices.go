@@ -0,0 +1,65 @@
+package main
+
+import (
+	"io"
+	"log"
+
+	"github.com/zegl/bazel_dependency_tools/internal/download"
+	"github.com/zegl/bazel_dependency_tools/internal/github"
+	"github.com/zegl/bazel_dependency_tools/internal/notices"
+	"github.com/zegl/bazel_dependency_tools/internal/sbom"
+	"github.com/zegl/bazel_dependency_tools/maven_jar"
+)
+
+// writeNotices writes a THIRD_PARTY_NOTICES file with the license texts and
+// NOTICE files of all dependencies
+func writeNotices(w io.Writer, workspace, prefixFilter string, downloader *download.Downloader, gitHub github.Hosts) error {
+	_, packages := collectPackages(workspace, prefixFilter)
+	results := collectLicenses(workspace, prefixFilter, downloader, gitHub)
+	return notices.Write(w, noticeDependencies(results, packages, downloader))
+}
+
+// noticeDependencies returns the license and NOTICE files of the dependencies.
+// The files of archives are found together with their license, the files of
+// Maven artifacts are read from their jars.
+func noticeDependencies(results []licenseResult, packages []sbom.Package, downloader *download.Downloader) []notices.Dependency {
+	type key struct{ rule, name string }
+	byName := make(map[key]sbom.Package)
+	for _, p := range packages {
+		byName[key{p.Rule, p.Name}] = p
+	}
+
+	var res []notices.Dependency
+	for _, r := range results {
+		d := notices.Dependency{
+			Name:  r.Name,
+			Files: r.Files,
+		}
+		if r.Err == nil {
+			d.License = r.License.String()
+		}
+		for _, declared := range r.License.Declared {
+			if declared.URL != "" {
+				d.URLs = append(d.URLs, declared.URL)
+			}
+		}
+
+		if p, ok := byName[key{r.Rule, r.Name}]; ok && p.Group != "" && p.URL != "" {
+			var sha256sum string
+			for _, h := range p.Hashes {
+				if h.Algorithm == sbom.SHA256 {
+					sha256sum = h.Value
+				}
+			}
+
+			files, err := maven_jar.LicenseFiles(downloader, p.URL, sha256sum)
+			if err != nil {
+				log.Println(r.Name, err)
+			}
+			d.Files = files
+		}
+
+		res = append(res, d)
+	}
+	return res
+}
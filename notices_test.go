@@ -0,0 +1,48 @@
+package main
+
+import (
+	"archive/zip"
+	"bytes"
+	"errors"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+
+	"github.com/zegl/bazel_dependency_tools/internal/download"
+	"github.com/zegl/bazel_dependency_tools/internal/notices"
+	"github.com/zegl/bazel_dependency_tools/maven_jar"
+)
+
+func TestNoticeDependencies(t *testing.T) {
+	var jar bytes.Buffer
+	zw := zip.NewWriter(&jar)
+	for name, content := range map[string]string{
+		"META-INF/LICENSE":     "Eclipse Public License - v 1.0",
+		"META-INF/MANIFEST.MF": "Manifest-Version: 1.0",
+		"org/junit/Test.class": "",
+	} {
+		f, err := zw.Create(name)
+		assert.Nil(t, err)
+		_, err = f.Write([]byte(content))
+		assert.Nil(t, err)
+	}
+	assert.Nil(t, zw.Close())
+
+	downloader := download.NewFakeDownloader(map[string][]byte{
+		"https://repo1.maven.org/maven2/junit/junit/4.12/junit-4.12.jar": jar.Bytes(),
+	})
+
+	_, packages := collectPackages("testdata/sbom_WORKSPACE", "")
+
+	deps := noticeDependencies([]licenseResult{
+		{Rule: "http_archive", Name: "io_bazel_rules_go", License: maven_jar.PomLicense{SPDX: "Apache-2.0"}, Files: map[string][]byte{"LICENSE.txt": []byte("Apache License")}},
+		{Rule: "maven_jar", Name: "junit_junit", License: maven_jar.PomLicense{SPDX: "EPL-1.0", Declared: []maven_jar.DeclaredLicense{{Name: "Eclipse Public License 1.0", URL: "http://www.eclipse.org/legal/epl-v10.html"}}}},
+		{Rule: "git_repository", Name: "bazel_skylib", Files: map[string][]byte{"NOTICE": []byte("Skylib")}, Err: errors.New("no license found")},
+	}, packages, downloader)
+
+	assert.Equal(t, []notices.Dependency{
+		{Name: "io_bazel_rules_go", License: "Apache-2.0", Files: map[string][]byte{"LICENSE.txt": []byte("Apache License")}},
+		{Name: "junit_junit", License: "EPL-1.0", URLs: []string{"http://www.eclipse.org/legal/epl-v10.html"}, Files: map[string][]byte{"META-INF/LICENSE": []byte("Eclipse Public License - v 1.0")}},
+		{Name: "bazel_skylib", Files: map[string][]byte{"NOTICE": []byte("Skylib")}},
+	}, deps)
+}
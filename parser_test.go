@@ -1,14 +1,24 @@
 package main
 
 import (
+	"bytes"
+	"crypto/sha256"
+	"fmt"
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"strings"
 	"testing"
 
 	"github.com/blang/semver"
 	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
 
 	"github.com/zegl/bazel_dependency_tools/http_archive"
 	"github.com/zegl/bazel_dependency_tools/internal"
+	"github.com/zegl/bazel_dependency_tools/internal/download"
 	"github.com/zegl/bazel_dependency_tools/internal/github"
+	"github.com/zegl/bazel_dependency_tools/maven_jar"
 )
 
 func TestParseWorkspace(t *testing.T) {
@@ -17,7 +27,7 @@ func TestParseWorkspace(t *testing.T) {
 	client.AddRelease("bazelbuild", "rules_go", "0.19.4", "https://github.com/bazelbuild/rules_go/releases/download/0.19.4/rules_go-0.19.4.tar.gz")
 	client.AddRelease("bazelbuild", "rules_sass", "1.23.1", "https://github.com/bazelbuild/rules_sass/archive/1.23.1.zip")
 
-	replacements := versionUpgradeReplacements("testdata/rules_go_0_19_3_WORKSPACE", "", client, nil)
+	replacements := internal.Replacements(findUpgrades("testdata/rules_go_0_19_3_WORKSPACE", "", http_archive.Sources{GitHub: github.Hosts{"github.com": client}}, nil))
 	assert.Equal(t, []internal.LineReplacement{
 		// rules_go multiple urls (tar.gz from release artifacts)
 		{Filename: "testdata/rules_go_0_19_3_WORKSPACE", Line: 6, Find: "0.19.3", Substitution: "0.19.4"},
@@ -39,17 +49,24 @@ func TestFindNewerVersion(t *testing.T) {
 	client := github.NewFakeClient()
 	client.AddRelease("bazelbuild", "rules_go", "0.19.4", "https://github.com/bazelbuild/rules_go/releases/download/0.19.4/rules_go-0.19.4.tar.gz") // https://github.com/bazelbuild/rules_go/releases/download/0.19.3/rules_go-0.19.3.tar.gz
 
-	existing, newest, shasum, err := http_archive.FindNewerGitHubRelease(client, "https://github.com/bazelbuild/rules_go/releases/download/0.19.3/rules_go-0.19.3.tar.gz")
-	assert.Nil(t, err)
-	assert.True(t, semver.MustParse(newest).GT(semver.MustParse("0.19.3")))
-	assert.Equal(t, "0.19.3", existing)
-	assert.Equal(t, "ae8c36ff6e565f674c7a3692d6a9ea1096e4c1ade497272c2108a810fb39acd2", shasum)
+	archive := []byte("rules_go-0.19.4")
+	downloader := download.NewFakeDownloader(map[string][]byte{
+		"https://github.com/bazelbuild/rules_go/releases/download/0.19.4/rules_go-0.19.4.tar.gz": archive,
+	})
+
+	release, err := http_archive.FindNewerGitHubRelease(client, downloader, "https://github.com/bazelbuild/rules_go/releases/download/0.19.3/rules_go-0.19.3.tar.gz")
+	require.NoError(t, err)
+	require.NotNil(t, release)
+	assert.True(t, semver.MustParse(release.NewVersion).GT(semver.MustParse("0.19.3")))
+	assert.Equal(t, "0.19.3", release.OldVersion)
+	assert.Equal(t, fmt.Sprintf("%x", sha256.Sum256(archive)), release.Sha256)
+	assert.Equal(t, "https://github.com/bazelbuild/rules_go/compare/0.19.3...0.19.4", release.CompareURL)
 }
 
 func TestReplace(t *testing.T) {
-	replacements := versionUpgradeReplacements("testdata/maven_jar_WORKSPACE", "", nil, func(c string) (string, string, error) {
+	replacements := internal.Replacements(findUpgrades("testdata/maven_jar_WORKSPACE", "", http_archive.Sources{}, func(c string) (string, string, error) {
 		return "11.22.33", "deadbeef", nil
-	})
+	}))
 
 	assert.Equal(t, []internal.LineReplacement{
 		{Filename: "testdata/maven_jar_WORKSPACE", Line: 3, Find: "3.3.3", Substitution: "11.22.33"},
@@ -62,12 +79,188 @@ func TestReplace(t *testing.T) {
 }
 
 func TestParseWorkspaceMavenInstall(t *testing.T) {
-	replacements := versionUpgradeReplacements("testdata/maven_install_WORKSPACE", "", nil, func(c string) (string, string, error) {
+	replacements := internal.Replacements(findUpgrades("testdata/maven_install_WORKSPACE", "", http_archive.Sources{}, func(c string) (string, string, error) {
 		return "11.22.33", "deadbeef", nil
-	})
+	}))
 	assert.Equal(t, []internal.LineReplacement{
 		{Filename: "testdata/maven_install_WORKSPACE", Line: 3, Find: "1.30.2", Substitution: "11.22.33"},
 		{Filename: "testdata/maven_install_WORKSPACE", Line: 10, Find: "4.1.0", Substitution: "11.22.33"},
 		{Filename: "testdata/maven_install_WORKSPACE", Line: 11, Find: "4.1.0", Substitution: "11.22.33"},
 	}, replacements)
 }
+
+func TestParseWorkspaceGitHubEnterprise(t *testing.T) {
+	client := github.NewFakeClient()
+	client.AddRelease("platform", "rules_internal", "1.1.0", "https://github.example.corp/platform/rules_internal/releases/download/1.1.0/rules_internal-1.1.0.tar.gz")
+
+	downloader := download.NewFakeDownloader(map[string][]byte{
+		"https://github.example.corp/platform/rules_internal/archive/1.1.0.zip": []byte("archive"),
+	})
+
+	upgrades := findUpgrades("testdata/github_enterprise_WORKSPACE", "", http_archive.Sources{GitHub: github.Hosts{"github.example.corp": client}, Download: downloader}, nil)
+	assert.Len(t, upgrades, 1)
+	assert.Equal(t, "github.example.corp/platform/rules_internal", upgrades[0].Coordinate)
+	assert.Equal(t, "https://github.example.corp/platform/rules_internal/compare/1.0.0...1.1.0", upgrades[0].CompareURL)
+	assert.Equal(t, []internal.LineReplacement{
+		{Filename: "testdata/github_enterprise_WORKSPACE", Line: 6, Find: "1.0.0", Substitution: "1.1.0"},
+		{Filename: "testdata/github_enterprise_WORKSPACE", Line: 5, Find: "1.0.0", Substitution: "1.1.0"},
+	}, upgrades[0].Replacements)
+}
+
+func TestParseWorkspaceVerifyMirrors(t *testing.T) {
+	client := github.NewFakeClient()
+	client.AddRelease("bazelbuild", "bazel-skylib", "1.0.2", "")
+	client.AddRelease("bazelbuild", "rules_pkg", "0.2.5", "")
+
+	downloader := download.NewFakeDownloader(map[string][]byte{
+		"https://mirror.bazel.build/github.com/bazelbuild/bazel-skylib/archive/1.0.2.tar.gz": []byte("skylib"),
+		"https://github.com/bazelbuild/bazel-skylib/archive/1.0.2.tar.gz":                    []byte("skylib"),
+		"https://github.com/bazelbuild/rules_pkg/archive/0.2.5.tar.gz":                       []byte("rules_pkg"),
+		"https://mirror.bazel.build/github.com/bazelbuild/rules_pkg/archive/0.2.5.tar.gz":    []byte("tampered"),
+	})
+
+	upgrades := findUpgrades("testdata/github_mirrors_WORKSPACE", "", http_archive.Sources{GitHub: github.Hosts{"github.com": client}, Download: downloader}, nil)
+	assert.Len(t, upgrades, 2)
+
+	assert.Nil(t, upgrades[0].Err)
+	assert.Equal(t, []internal.LineReplacement{
+		{Filename: "testdata/github_mirrors_WORKSPACE", Line: 7, Find: "1.0.0", Substitution: "1.0.2"},
+		{Filename: "testdata/github_mirrors_WORKSPACE", Line: 8, Find: "1.0.0", Substitution: "1.0.2"},
+		{Filename: "testdata/github_mirrors_WORKSPACE", Line: 5, Find: "2ea8a5ed2b448baf4a6855d3ce049c4c452a6470b1efd1504fdb7c1c134d220a", Substitution: "ac72bf456474cce3abfd0c586214414a352e12022e8966ac10206e1dc0cc15e4"},
+	}, upgrades[0].Replacements)
+
+	assert.EqualError(t, upgrades[1].Err, "checksum mismatch for mirror https://mirror.bazel.build/github.com/bazelbuild/rules_pkg/archive/0.2.5.tar.gz: expected c500fe37750948afae22c84301e869fd15e42b5e8104abbdf7a5bbb0a156fac2, got d121be3103007b41edf96f8262925f8c7d61894afe9a041843b631f69445bc57")
+	assert.Nil(t, upgrades[1].Replacements)
+}
+
+func TestVerifyChecksums(t *testing.T) {
+	downloader := download.NewFakeDownloader(map[string][]byte{
+		"https://github.com/bazelbuild/rules_pkg/archive/0.2.4.tar.gz":                      []byte("rules_pkg"),
+		"https://mirror.bazel.build/github.com/bazelbuild/rules_pkg/archive/0.2.4.tar.gz":   []byte("tampered"),
+		"https://github.com/bazelbuild/buildtools/releases/download/0.29.0/buildifier":      []byte("buildifier"),
+		"https://repo1.maven.org/maven2/com/google/guava/guava/28.1-jre/guava-28.1-jre.jar": []byte("guava"),
+		"https://github.com/bazelbuild/buildtools/releases/download/0.29.0/buildozer":       []byte("buildozer"),
+	})
+
+	var out bytes.Buffer
+	ok := verifyChecksums(&out, "testdata/verify_WORKSPACE", "", downloader)
+	assert.False(t, ok)
+	assert.Equal(t, `testdata/verify_WORKSPACE:3: FAIL http_archive rules_pkg: checksum mismatch for https://mirror.bazel.build/github.com/bazelbuild/rules_pkg/archive/0.2.4.tar.gz: expected c500fe37750948afae22c84301e869fd15e42b5e8104abbdf7a5bbb0a156fac2, got d121be3103007b41edf96f8262925f8c7d61894afe9a041843b631f69445bc57
+OK http_file buildifier
+testdata/verify_WORKSPACE:18: WARNING http_jar checker: no checksum declared
+OK maven_jar com_google_guava_guava
+OK http_file buildozer
+`, out.String())
+
+	out.Reset()
+	assert.True(t, verifyChecksums(&out, "testdata/verify_WORKSPACE", "com_google", downloader))
+
+	// Archives are downloaded even if they're in the repository cache
+	cache, err := ioutil.TempDir("", "repository_cache")
+	assert.Nil(t, err)
+	defer os.RemoveAll(cache)
+	cached := filepath.Join(cache, "content_addressable", "sha256", "c500fe37750948afae22c84301e869fd15e42b5e8104abbdf7a5bbb0a156fac2", "file")
+	assert.Nil(t, os.MkdirAll(filepath.Dir(cached), 0755))
+	assert.Nil(t, ioutil.WriteFile(cached, []byte("rules_pkg"), 0644))
+	downloader.Cache = cache
+
+	out.Reset()
+	assert.False(t, verifyChecksums(&out, "testdata/verify_WORKSPACE", "rules_pkg", downloader))
+	assert.Contains(t, out.String(), "FAIL http_archive rules_pkg: checksum mismatch for https://mirror.bazel.build/github.com/bazelbuild/rules_pkg/archive/0.2.4.tar.gz")
+
+	// Verified archives are added to the cache, others are not
+	buildifier := filepath.Join(cache, "content_addressable", "sha256", fmt.Sprintf("%x", sha256.Sum256([]byte("buildifier"))), "file")
+	out.Reset()
+	assert.True(t, verifyChecksums(&out, "testdata/verify_WORKSPACE", "buildifier", downloader))
+	_, err = os.Stat(buildifier)
+	assert.Nil(t, err)
+	_, err = os.Stat(filepath.Join(cache, "content_addressable", "sha256", fmt.Sprintf("%x", sha256.Sum256([]byte("tampered"))), "file"))
+	assert.True(t, os.IsNotExist(err))
+}
+
+func TestPinChecksums(t *testing.T) {
+	downloader := download.NewFakeDownloader(map[string][]byte{
+		"https://github.com/bazelbuild/rules_pkg/archive/0.2.4.tar.gz":                    []byte("rules_pkg"),
+		"https://mirror.bazel.build/github.com/bazelbuild/rules_pkg/archive/0.2.4.tar.gz": []byte("rules_pkg"),
+		"https://github.com/bazelbuild/buildtools/releases/download/0.29.0/buildifier":    []byte("buildifier"),
+	})
+
+	workspace, cleanup := copyWorkspace(t, "testdata/pin_WORKSPACE")
+	defer cleanup()
+
+	assert.Nil(t, pinChecksums(workspace, "", downloader))
+
+	pinned, err := ioutil.ReadFile(workspace)
+	assert.Nil(t, err)
+	assert.Equal(t, `load("@bazel_tools//tools/build_defs/repo:http.bzl", "http_archive", "http_file")
+
+http_archive(
+    name = "rules_pkg",
+    sha256 = "c500fe37750948afae22c84301e869fd15e42b5e8104abbdf7a5bbb0a156fac2",
+    urls = [
+        "https://github.com/bazelbuild/rules_pkg/archive/0.2.4.tar.gz",
+        "https://mirror.bazel.build/github.com/bazelbuild/rules_pkg/archive/0.2.4.tar.gz",
+    ],
+)
+
+http_archive(
+    name = "bazel_skylib",
+    sha256 = "2ea8a5ed2b448baf4a6855d3ce049c4c452a6470b1efd1504fdb7c1c134d220a",
+    urls = ["https://github.com/bazelbuild/bazel-skylib/archive/1.0.0.tar.gz"],
+)
+
+http_file(name = "buildifier", sha256 = "cbd7a653c3d82055e47298856026e6cb77bfde1e3ce9107fd3f7764483a3751b", urls = ["https://github.com/bazelbuild/buildtools/releases/download/0.29.0/buildifier"])
+`, string(pinned))
+}
+
+func TestMigrateIntegrity(t *testing.T) {
+	workspace, cleanup := copyWorkspace(t, "testdata/github_mirrors_WORKSPACE")
+	defer cleanup()
+
+	assert.Nil(t, migrateIntegrity(workspace, "rules_"))
+
+	migrated, err := ioutil.ReadFile(workspace)
+	assert.Nil(t, err)
+	assert.Contains(t, string(migrated), `    sha256 = "2ea8a5ed2b448baf4a6855d3ce049c4c452a6470b1efd1504fdb7c1c134d220a",`)
+	assert.Contains(t, string(migrated), `    integrity = "sha256-S6j0qw/4XySEKHqwbA2HHcsxzFTUOUV9KP1K4UsYRQo=",`)
+	assert.NotContains(t, string(migrated), "4ba8f4ab0ff85f2484287ab06c0d871dcb31cc54d439457d28fd4ae14b18450a")
+}
+
+// copyWorkspace copies a workspace to a temporary directory, so that it can
+// be modified by the test
+func copyWorkspace(t *testing.T, path string) (string, func()) {
+	dir, err := ioutil.TempDir("", "workspace")
+	assert.Nil(t, err)
+
+	content, err := ioutil.ReadFile(path)
+	assert.Nil(t, err)
+	workspace := filepath.Join(dir, "WORKSPACE")
+	assert.Nil(t, ioutil.WriteFile(workspace, content, 0644))
+
+	return workspace, func() { os.RemoveAll(dir) }
+}
+
+func TestAlignUpgrades(t *testing.T) {
+	fixedVersion := func(versions map[string]string) maven_jar.NewestVersionResolver {
+		return func(coordinate string) (string, string, error) {
+			xyz := strings.Split(coordinate, ":")
+			if version, ok := versions[xyz[0]+":"+xyz[1]]; ok {
+				return version, "fakesha1", nil
+			}
+			return xyz[2], "", nil
+		}
+	}
+
+	upgrades := mavenUpgrades("testdata/sbom_WORKSPACE", "", fixedVersion(map[string]string{
+		"junit:junit":            "4.13.2",
+		"com.google.guava:guava": "30.0-jre",
+	}))
+	aligned := map[string]string{"junit:junit": "4.13.1"}
+
+	res := alignUpgrades(upgrades, mavenUpgrades("testdata/sbom_WORKSPACE", "", fixedVersion(aligned)), aligned)
+	assert.Len(t, res, 2)
+	assert.Equal(t, "junit:junit", res[0].Coordinate)
+	assert.Equal(t, "4.13.1", res[0].NewVersion)
+	assert.Equal(t, "com.google.guava:guava", res[1].Coordinate)
+	assert.Equal(t, "30.0-jre", res[1].NewVersion)
+}
@@ -0,0 +1,129 @@
+package main
+
+import (
+	"fmt"
+	"io"
+	"log"
+	"path/filepath"
+	"strings"
+	"time"
+
+	"go.starlark.net/syntax"
+
+	"github.com/zegl/bazel_dependency_tools/go_repository"
+	"github.com/zegl/bazel_dependency_tools/http_archive"
+	"github.com/zegl/bazel_dependency_tools/internal/download"
+	"github.com/zegl/bazel_dependency_tools/internal/github"
+	"github.com/zegl/bazel_dependency_tools/internal/sbom"
+	"github.com/zegl/bazel_dependency_tools/maven_jar"
+	"github.com/zegl/bazel_dependency_tools/parse"
+)
+
+// collectPackages returns the name of the workspace, and all external
+// repositories in it. The licenses are not set.
+func collectPackages(workspace, prefixFilter string) (string, []sbom.Package) {
+	// Use the name of the directory if the workspace has no name
+	abs, _ := filepath.Abs(workspace)
+	name := filepath.Base(filepath.Dir(abs))
+
+	var packages []sbom.Package
+
+	add := func(p *sbom.Package, err error) {
+		if err != nil {
+			log.Println(err)
+			return
+		}
+		if p != nil {
+			packages = append(packages, *p)
+		}
+	}
+
+	httpHook := func(rule string) parse.FuncHook {
+		return func(s *syntax.CallExpr, namePrefixFilter string, workspacePath string) error {
+			add(http_archive.Package(s, rule, namePrefixFilter))
+			return nil
+		}
+	}
+
+	callFuncs := map[string]parse.FuncHook{
+		"workspace": func(s *syntax.CallExpr, namePrefixFilter string, workspacePath string) error {
+			if n := callName(s); n != "" {
+				name = n
+			}
+			return nil
+		},
+		"http_archive": httpHook("http_archive"),
+		"http_file":    httpHook("http_file"),
+		"http_jar":     httpHook("http_jar"),
+		"git_repository": func(s *syntax.CallExpr, namePrefixFilter string, workspacePath string) error {
+			add(http_archive.GitRepositoryPackage(s, namePrefixFilter))
+			return nil
+		},
+		"go_repository": func(s *syntax.CallExpr, namePrefixFilter string, workspacePath string) error {
+			add(go_repository.Package(s, namePrefixFilter))
+			return nil
+		},
+		"maven_jar": func(s *syntax.CallExpr, namePrefixFilter string, workspacePath string) error {
+			p, err := maven_jar.Package(s, namePrefixFilter)
+			if err == maven_jar.ErrSkipped {
+				return nil
+			}
+			add(p, err)
+			return nil
+		},
+		"maven_install": func(s *syntax.CallExpr, namePrefixFilter string, workspacePath string) error {
+			ps, err := maven_jar.PackagesMavenInstall(s, namePrefixFilter, workspacePath)
+			if err == maven_jar.ErrSkipped {
+				return nil
+			}
+			if err != nil {
+				log.Println(callName(s), err)
+				return nil
+			}
+			packages = append(packages, ps...)
+			return nil
+		},
+	}
+	parse.ParseWorkspace(workspace, prefixFilter, callFuncs)
+
+	return name, packages
+}
+
+// addLicenses sets the licenses of the packages from the results of collectLicenses
+func addLicenses(packages []sbom.Package, results []licenseResult) {
+	type key struct{ rule, name string }
+	licenses := make(map[key]maven_jar.PomLicense)
+	for _, r := range results {
+		if r.Err == nil {
+			licenses[key{r.Rule, r.Name}] = r.License
+		}
+	}
+
+	for i, p := range packages {
+		if l, ok := licenses[key{p.Rule, p.Name}]; ok {
+			packages[i].License = string(l.SPDX)
+			packages[i].UnknownLicense = strings.Join(l.Unknown, ", ")
+		}
+	}
+}
+
+// writeSBOM writes an SBOM of all external repositories in the workspace, in
+// the "spdx" or "cyclonedx" format
+func writeSBOM(w io.Writer, format, workspace, prefixFilter string, downloader *download.Downloader, gitHub github.Hosts) error {
+	write, ok := map[string]func(io.Writer, *sbom.Document) error{
+		"spdx":      sbom.WriteSPDX,
+		"cyclonedx": sbom.WriteCycloneDX,
+	}[format]
+	if !ok {
+		return fmt.Errorf("unknown SBOM format %q, expected spdx or cyclonedx", format)
+	}
+
+	name, packages := collectPackages(workspace, prefixFilter)
+	addLicenses(packages, collectLicenses(workspace, prefixFilter, downloader, gitHub))
+
+	return write(w, &sbom.Document{
+		Name:     name,
+		Created:  time.Now(),
+		Packages: packages,
+	})
+}
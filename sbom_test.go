@@ -0,0 +1,111 @@
+package main
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+
+	"github.com/zegl/bazel_dependency_tools/internal/sbom"
+	"github.com/zegl/bazel_dependency_tools/maven_jar"
+)
+
+func TestCollectPackages(t *testing.T) {
+	name, packages := collectPackages("testdata/sbom_WORKSPACE", "")
+	assert.Equal(t, "example", name)
+	assert.Equal(t, []sbom.Package{
+		{
+			Rule:    "http_archive",
+			Name:    "io_bazel_rules_go",
+			Version: "v0.20.2",
+			PURL:    "pkg:github/bazelbuild/rules_go@v0.20.2",
+			URL:     "https://storage.googleapis.com/bazel-mirror/github.com/bazelbuild/rules_go/releases/download/v0.20.2/rules_go-v0.20.2.tar.gz",
+			Hashes:  []sbom.Hash{{Algorithm: sbom.SHA256, Value: "b9aa86ec08a292b97ec4591cf578e020b35f98e12173bbd4a921f84f583aebd9"}},
+		},
+		{
+			Rule:   "http_file",
+			Name:   "tool",
+			PURL:   "pkg:generic/tool?download_url=https%3A%2F%2Fexample.com%2Fdownloads%2Ftool.bin",
+			URL:    "https://example.com/downloads/tool.bin",
+			Hashes: []sbom.Hash{{Algorithm: sbom.SHA256, Value: "b9aa86ec08a292b98bec4591cf78e020b35f98e12173bbd4a921f84f583aebe9"}},
+		},
+		{
+			Rule:    "git_repository",
+			Name:    "bazel_skylib",
+			Version: "1.0.0",
+			PURL:    "pkg:github/bazelbuild/bazel-skylib@1.0.0",
+			URL:     "git+https://github.com/bazelbuild/bazel-skylib.git@1.0.0",
+		},
+		{
+			Rule:    "go_repository",
+			Name:    "com_github_google_go_github_v28",
+			Version: "v28.1.1",
+			PURL:    "pkg:golang/github.com/google/go-github/v28@v28.1.1",
+			URL:     "https://proxy.golang.org/github.com/google/go-github/v28/@v/v28.1.1.zip",
+		},
+		{
+			Rule:    "go_repository",
+			Name:    "com_github_burntsushi_toml",
+			Version: "v0.3.1",
+			PURL:    "pkg:golang/github.com/BurntSushi/toml@v0.3.1",
+			URL:     "https://proxy.golang.org/github.com/!burnt!sushi/toml/@v/v0.3.1.zip",
+		},
+		{
+			Rule:     "maven_jar",
+			Name:     "junit_junit",
+			Group:    "junit",
+			Artifact: "junit",
+			Version:  "4.12",
+			PURL:     "pkg:maven/junit/junit@4.12",
+			URL:      "https://repo1.maven.org/maven2/junit/junit/4.12/junit-4.12.jar",
+			Hashes:   []sbom.Hash{{Algorithm: sbom.SHA1, Value: "2973d150c0dc1fefe998f834810d68f278ea58ec"}},
+		},
+		{
+			Rule:     "maven_install",
+			Name:     "com.google.guava:failureaccess:1.0.1",
+			Group:    "com.google.guava",
+			Artifact: "failureaccess",
+			Version:  "1.0.1",
+			PURL:     "pkg:maven/com.google.guava/failureaccess@1.0.1",
+			URL:      "https://repo1.maven.org/maven2/com/google/guava/failureaccess/1.0.1/failureaccess-1.0.1.jar",
+			Hashes:   []sbom.Hash{{Algorithm: sbom.SHA256, Value: "a171ee4c734dd2da837e4b16be9df4661afab72a41adaf31eb84dfdaf936ca26"}},
+		},
+		{
+			Rule:     "maven_install",
+			Name:     "com.google.guava:guava:28.1-jre",
+			Group:    "com.google.guava",
+			Artifact: "guava",
+			Version:  "28.1-jre",
+			PURL:     "pkg:maven/com.google.guava/guava@28.1-jre",
+			URL:      "https://repo1.maven.org/maven2/com/google/guava/guava/28.1-jre/guava-28.1-jre.jar",
+			Hashes:   []sbom.Hash{{Algorithm: sbom.SHA256, Value: "30f7ba2c6da5e5e5e3ae4c3d5a4c1fe2b0bb3ee2c3bd5e8d7c4ff3ab3e0b52cc"}},
+		},
+		{
+			Rule:     "maven_install",
+			Name:     "com.google.guava:guava:jar:sources:28.1-jre",
+			Group:    "com.google.guava",
+			Artifact: "guava",
+			Version:  "28.1-jre",
+			PURL:     "pkg:maven/com.google.guava/guava@28.1-jre?classifier=sources",
+			URL:      "https://repo1.maven.org/maven2/com/google/guava/guava/28.1-jre/guava-28.1-jre-sources.jar",
+			Hashes:   []sbom.Hash{{Algorithm: sbom.SHA256, Value: "f0b9e12a9d9fc6d6c2bbbd4ee4bcd39fe1b53fc1bf9b2d3c1b7a2b3ba1b8a6f1"}},
+		},
+	}, packages)
+}
+
+func TestAddLicenses(t *testing.T) {
+	packages := []sbom.Package{
+		{Rule: "maven_jar", Name: "junit_junit"},
+		{Rule: "maven_install", Name: "com.example:custom:1.0"},
+		{Rule: "http_archive", Name: "missing"},
+	}
+
+	addLicenses(packages, []licenseResult{
+		{Rule: "maven_jar", Name: "junit_junit", License: maven_jar.PomLicense{SPDX: "EPL-1.0"}},
+		{Rule: "maven_install", Name: "com.example:custom:1.0", License: maven_jar.PomLicense{SPDX: "MIT", Unknown: []string{"Example Corp License"}}},
+	})
+
+	assert.Equal(t, "EPL-1.0", packages[0].License)
+	assert.Equal(t, "MIT", packages[1].License)
+	assert.Equal(t, "Example Corp License", packages[1].UnknownLicense)
+	assert.Equal(t, "", packages[2].License)
+}